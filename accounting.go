@@ -0,0 +1,189 @@
+package bee
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Usage 是一个任务名称或租户在统计周期内的资源用量。
+type Usage struct {
+	Tasks        int64   `json:"tasks"`         // 结束的任务数量
+	Errors       int64   `json:"errors"`        // 失败或 panic 的任务数量
+	SlotSeconds  float64 `json:"slot_seconds"`  // 占用槽位的总秒数
+	QueueSeconds float64 `json:"queue_seconds"` // 排队等待的总秒数
+}
+
+// add 累加一个任务的用量。
+func (u *Usage) add(info TaskInfo) {
+	u.Tasks++
+	if info.Err != nil {
+		u.Errors++
+	}
+	u.SlotSeconds += info.SlotTime.Seconds()
+	u.QueueSeconds += info.QueueTime().Seconds()
+}
+
+// Report 是一个统计周期的用量报告。
+type Report struct {
+	Start    time.Time        `json:"start"`     // 周期开始时间
+	End      time.Time        `json:"end"`       // 周期结束时间
+	ByName   map[string]Usage `json:"by_name"`   // 按任务名称统计
+	ByTenant map[string]Usage `json:"by_tenant"` // 按租户统计
+}
+
+// WriteJSON 将报告以 JSON 格式写入 w。
+//
+// 参数:
+//
+//	w io.Writer: 输出目标。
+//
+// 返回值:
+//
+//	error: 写入失败时返回的错误。
+func (r Report) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// WriteCSV 将报告以 CSV 格式写入 w，每个任务名称和租户各占一行。
+//
+// 列依次为 start、end、dimension（name 或 tenant）、key、tasks、errors、slot_seconds、queue_seconds。
+//
+// 参数:
+//
+//	w io.Writer: 输出目标。
+//
+// 返回值:
+//
+//	error: 写入失败时返回的错误。
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"start", "end", "dimension", "key", "tasks", "errors", "slot_seconds", "queue_seconds"})
+	start, end := r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339)
+	for _, dim := range []struct {
+		name  string
+		usage map[string]Usage
+	}{{"name", r.ByName}, {"tenant", r.ByTenant}} {
+		keys := make([]string, 0, len(dim.usage))
+		for k := range dim.usage {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			u := dim.usage[k]
+			cw.Write([]string{
+				start, end, dim.name, k,
+				strconv.FormatInt(u.Tasks, 10),
+				strconv.FormatInt(u.Errors, 10),
+				strconv.FormatFloat(u.SlotSeconds, 'f', 3, 64),
+				strconv.FormatFloat(u.QueueSeconds, 'f', 3, 64),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// NewLedger 创建一个用量账本，通过 WithObserver 挂到一个或多个线程池上。
+//
+// 返回值:
+//
+//	*Ledger: 用量账本。
+func NewLedger() *Ledger {
+	return &Ledger{start: time.Now(), byName: map[string]*Usage{}, byTenant: map[string]*Usage{}}
+}
+
+// Ledger 按任务名称和租户累计任务的资源用量，实现了 Observer。
+type Ledger struct {
+	mu       sync.Mutex
+	start    time.Time
+	byName   map[string]*Usage
+	byTenant map[string]*Usage
+}
+
+// TaskDone 累计一个已结束任务的用量。
+func (l *Ledger) TaskDone(info TaskInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	usageOf(l.byName, info.Name).add(info)
+	usageOf(l.byTenant, info.Tenant).add(info)
+}
+
+// usageOf 返回 key 对应的用量，不存在时创建。
+func usageOf(m map[string]*Usage, key string) *Usage {
+	u := m[key]
+	if u == nil {
+		u = &Usage{}
+		m[key] = u
+	}
+	return u
+}
+
+// Report 返回当前周期到目前为止的用量报告。
+//
+// 返回值:
+//
+//	Report: 用量报告。
+func (l *Ledger) Report() Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reportLocked(time.Now())
+}
+
+// Reset 结束当前周期并开始新周期，返回被结束周期的用量报告。
+//
+// 返回值:
+//
+//	Report: 被结束周期的用量报告。
+func (l *Ledger) Reset() Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	r := l.reportLocked(now)
+	l.start = now
+	l.byName = map[string]*Usage{}
+	l.byTenant = map[string]*Usage{}
+	return r
+}
+
+// Every 每隔 interval 结束一个周期，并将该周期的报告交给 export，直到 ctx 取消。
+//
+// 参数:
+//
+//	ctx context.Context: 控制周期导出的上下文。
+//	interval time.Duration: 统计周期长度。
+//	export func(Report): 导出报告的函数，例如调用 WriteCSV 写入文件。
+func (l *Ledger) Every(ctx context.Context, interval time.Duration, export func(Report)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			export(l.Reset())
+		}
+	}
+}
+
+// reportLocked 生成用量报告，调用方需持有 l.mu。
+func (l *Ledger) reportLocked(end time.Time) Report {
+	r := Report{
+		Start:    l.start,
+		End:      end,
+		ByName:   make(map[string]Usage, len(l.byName)),
+		ByTenant: make(map[string]Usage, len(l.byTenant)),
+	}
+	for k, u := range l.byName {
+		r.ByName[k] = *u
+	}
+	for k, u := range l.byTenant {
+		r.ByTenant[k] = *u
+	}
+	return r
+}
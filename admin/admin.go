@@ -0,0 +1,272 @@
+// Package admin 提供通过 Unix 套接字管理进程内线程池的接口。
+//
+// 服务端在进程内监听一个 Unix 套接字，客户端（例如 bee ctl 命令）每行发送一个 JSON 请求，
+// 服务端每行返回一个 JSON 响应，无需暴露 HTTP 端口。
+package admin
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// 支持的命令。
+const (
+	CmdList      = "list"       // 列出线程池名称
+	CmdStats     = "stats"      // 返回线程池状态，未指定线程池时返回全部
+	CmdPause     = "pause"      // 暂停分配槽位
+	CmdResume    = "resume"     // 恢复分配槽位
+	CmdResize    = "resize"     // 调整容量
+	CmdDrain     = "drain"      // 退出线程池并等待运行中的任务结束
+	CmdDumpTasks = "dump-tasks" // 返回运行中和排队中的任务
+)
+
+// Request 是客户端发送的请求。
+type Request struct {
+	Cmd     string        `json:"cmd"`               // 命令
+	Pool    string        `json:"pool,omitempty"`    // 线程池名称
+	Size    int           `json:"size,omitempty"`    // resize 的新容量
+	Timeout time.Duration `json:"timeout,omitempty"` // drain 的等待时间，0 表示一直等待
+}
+
+// Response 是服务端返回的响应。
+type Response struct {
+	Error string          `json:"error,omitempty"` // 错误信息，为空表示成功
+	Data  json.RawMessage `json:"data,omitempty"`  // 命令的结果
+}
+
+// NewServer 创建一个管理服务端。
+//
+// 返回值:
+//
+//	*Server: 管理服务端。
+func NewServer() *Server {
+	return &Server{pools: map[string]*bee.Pool{}}
+}
+
+// Server 是进程内的管理服务端。
+type Server struct {
+	mu    sync.Mutex
+	pools map[string]*bee.Pool
+	ln    net.Listener
+	path  string // Listen 创建的套接字路径，Close 时删除
+}
+
+// Register 注册线程池，以线程池名称作为管理时使用的名称。
+//
+// 参数:
+//
+//	pools ...*bee.Pool: 要注册的线程池，名称为空或重复时后注册的覆盖先注册的。
+func (s *Server) Register(pools ...*bee.Pool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range pools {
+		s.pools[p.Name()] = p
+	}
+}
+
+// Listen 在 path 上监听 Unix 套接字并在后台处理请求。
+//
+// path 上残留的套接字文件会被删除，套接字文件权限为 0600，只允许同一用户访问。
+// 套接字先在同一目录下权限为 0700 的临时目录中创建，设置权限后再移动到 path，
+// 其他用户不会在设置权限之前连接到套接字。
+//
+// 参数:
+//
+//	path string: 套接字路径。
+//
+// 返回值:
+//
+//	error: 监听失败或 path 上已存在其他文件时返回的错误。
+func (s *Server) Listen(path string) error {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return &os.PathError{Op: "listen", Path: path, Err: os.ErrExist}
+		}
+		os.Remove(path)
+	}
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".bee-admin-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.Listen("unix", tmp)
+	if err != nil {
+		return err
+	}
+	// 套接字文件移动后由 Close 删除。
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		ln.Close()
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return err
+	}
+	s.mu.Lock()
+	s.path, s.ln = path, ln
+	s.mu.Unlock()
+	go s.Serve(ln)
+	return nil
+}
+
+// Serve 在 ln 上接受连接并处理请求，直到 ln 被关闭。
+//
+// 参数:
+//
+//	ln net.Listener: 监听器。
+//
+// 返回值:
+//
+//	error: 接受连接失败时返回的错误，Close 后返回 nil。
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close 停止监听，Unix 套接字文件会被删除。
+//
+// 返回值:
+//
+//	error: 关闭监听器的错误。
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		os.Remove(s.path)
+		s.path = ""
+	}
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Close()
+}
+
+// serveConn 处理一个连接上的全部请求。
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for sc.Scan() {
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			resp.Error = err.Error()
+		} else if data, err := s.handle(req); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Data, _ = json.Marshal(data)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle 执行一个请求。
+func (s *Server) handle(req Request) (any, error) {
+	switch req.Cmd {
+	case CmdList:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		names := make([]string, 0, len(s.pools))
+		for name := range s.pools {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return names, nil
+	case CmdStats:
+		if req.Pool == "" {
+			return s.allStats(), nil
+		}
+	}
+
+	p, err := s.pool(req.Pool)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Cmd {
+	case CmdStats:
+		return []bee.Stats{p.Stats()}, nil
+	case CmdPause:
+		p.Pause()
+		return p.Stats(), nil
+	case CmdResume:
+		p.Resume()
+		return p.Stats(), nil
+	case CmdResize:
+		if req.Size <= 0 {
+			return nil, fmt.Errorf("admin: invalid size %d", req.Size)
+		}
+		p.Resize(req.Size)
+		return p.Stats(), nil
+	case CmdDrain:
+		ctx := context.Background()
+		if req.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+			defer cancel()
+		}
+		if err := p.Shutdown(ctx); err != nil {
+			return nil, err
+		}
+		return p.Stats(), nil
+	case CmdDumpTasks:
+		return p.Tasks(), nil
+	default:
+		return nil, fmt.Errorf("admin: unknown command %q", req.Cmd)
+	}
+}
+
+// pool 按名称查找线程池。
+func (s *Server) pool(name string) (*bee.Pool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("admin: unknown pool %q", name)
+	}
+	return p, nil
+}
+
+// allStats 返回全部线程池的状态，按名称排序。
+func (s *Server) allStats() []bee.Stats {
+	s.mu.Lock()
+	pools := make([]*bee.Pool, 0, len(s.pools))
+	for _, p := range s.pools {
+		pools = append(pools, p)
+	}
+	s.mu.Unlock()
+
+	stats := make([]bee.Stats, 0, len(pools))
+	for _, p := range pools {
+		stats = append(stats, p.Stats())
+	}
+	slices.SortFunc(stats, func(a, b bee.Stats) int { return cmp.Compare(a.Name, b.Name) })
+	return stats
+}
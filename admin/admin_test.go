@@ -0,0 +1,64 @@
+//go:build unix
+
+package admin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestListenSocketPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bee.sock")
+	s := NewServer()
+	s.Register(bee.New(context.Background(), 1, bee.WithName("jobs")))
+	if err := s.Listen(path); err != nil {
+		t.Fatalf("Listen() = %v", err)
+	}
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, want a socket with 0600", fi.Mode())
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, ".bee-admin-*")); len(left) != 0 {
+		t.Fatalf("temporary directories left behind: %v", left)
+	}
+
+	c, err := Dial(path)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	var names []string
+	if err := c.Call(Request{Cmd: CmdList}, &names); err != nil || !slices.Equal(names, []string{"jobs"}) {
+		t.Fatalf("list = %v, %v", names, err)
+	}
+	c.Close()
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket left after Close: %v", err)
+	}
+}
+
+func TestListenKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bee.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewServer().Listen(path); err == nil {
+		t.Fatal("Listen() over a regular file = nil")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "data" {
+		t.Fatalf("regular file changed: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+// Dial 连接到管理服务端的 Unix 套接字。
+//
+// 参数:
+//
+//	path string: 套接字路径。
+//
+// 返回值:
+//
+//	*Client: 管理客户端。
+//	error: 连接失败时返回的错误。
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Client 是管理客户端，同一时间只能发送一个请求。
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Call 发送请求，并将结果解码到 out 中。
+//
+// 参数:
+//
+//	req Request: 请求。
+//	out any: 结果的解码目标，为 nil 时忽略结果。
+//
+// 返回值:
+//
+//	error: 通信失败或服务端返回的错误。
+func (c *Client) Call(req Request, out any) error {
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return err
+	}
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	if out == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// Close 关闭连接。
+//
+// 返回值:
+//
+//	error: 关闭连接的错误。
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,344 @@
+// Package audit 将每个结束的任务以一行 JSON 的形式写入按大小或时间轮转的审计日志。
+//
+// 使用 bee.WithObserver 将 Writer 挂到线程池上即可记录该线程池执行的全部任务。
+package audit
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// 任务的执行结果。
+const (
+	OutcomeOK    = "ok"    // 正常结束
+	OutcomeError = "error" // 通过 bee.Fail 报告了错误
+	OutcomePanic = "panic" // 发生了 panic
+)
+
+// Entry 是审计日志中的一行。
+type Entry struct {
+	Pool      string            `json:"pool"`
+	Index     int64             `json:"index"`
+	Name      string            `json:"name,omitempty"`
+	Tenant    string            `json:"tenant,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Submitter string            `json:"submitter,omitempty"`
+	Submitted time.Time         `json:"submitted"`
+	Started   time.Time         `json:"started"`
+	Finished  time.Time         `json:"finished"`
+	Outcome   string            `json:"outcome"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// NewEntry 根据任务信息生成审计日志条目。
+//
+// 参数:
+//
+//	info bee.TaskInfo: 已结束任务的信息。
+//
+// 返回值:
+//
+//	Entry: 审计日志条目。
+func NewEntry(info bee.TaskInfo) Entry {
+	e := Entry{
+		Pool:      info.Pool,
+		Index:     info.Index,
+		Name:      info.Name,
+		Tenant:    info.Tenant,
+		Labels:    info.Labels,
+		Submitter: info.Submitter,
+		Submitted: info.Submitted,
+		Started:   info.Started,
+		Finished:  info.Finished,
+		Outcome:   OutcomeOK,
+	}
+	if info.Err != nil {
+		e.Outcome = OutcomeError
+		var pe *bee.PanicError
+		if errors.As(info.Err, &pe) {
+			e.Outcome = OutcomePanic
+		}
+		e.Error = info.Err.Error()
+	}
+	return e
+}
+
+// Options 是审计日志的轮转配置。
+type Options struct {
+	MaxSize    int64         // 单个文件的最大字节数，超过时轮转，0 表示不按大小轮转
+	MaxAge     time.Duration // 单个文件的最长写入时间，超过时轮转，0 表示不按时间轮转
+	MaxBackups int           // 最多保留的轮转文件数量，0 表示全部保留
+	Compress   bool          // 是否使用 gzip 压缩轮转后的文件
+	Sync       bool          // 是否在每行写入后同步到磁盘
+}
+
+// New 打开审计日志文件，文件已存在时继续追加。
+//
+// 参数:
+//
+//	path string: 审计日志路径，轮转后的文件位于同一目录下，以 path 加时间戳命名。
+//	opts Options: 轮转配置。
+//
+// 返回值:
+//
+//	*Writer: 审计日志。
+//	error: 打开文件失败时返回的错误。
+func New(path string, opts Options) (*Writer, error) {
+	w := &Writer{path: path, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Writer 是轮转的审计日志，实现了 bee.Observer。
+type Writer struct {
+	path string
+	opts Options
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	err    error // 最近一次写入错误
+
+	pending []string       // 等待后台压缩和清理的轮转文件
+	working bool           // 后台是否正在压缩和清理
+	bg      sync.WaitGroup // 后台压缩和清理
+}
+
+// rotatedLayout 是轮转文件名中时间戳的格式。
+const rotatedLayout = "20060102T150405.000000000"
+
+// TaskDone 写入一个已结束任务的审计记录。
+func (w *Writer) TaskDone(info bee.TaskInfo) {
+	line, err := json.Marshal(NewEntry(info))
+	if err != nil {
+		w.setErr(err)
+		return
+	}
+	w.setErr(w.write(append(line, '\n')))
+}
+
+// Err 返回最近一次写入失败的错误。
+//
+// 返回值:
+//
+//	error: 写入错误，没有错误时为 nil。
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close 关闭审计日志，并等待后台的压缩和清理结束。
+//
+// 返回值:
+//
+//	error: 关闭文件的错误。
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.bg.Wait()
+	return err
+}
+
+// setErr 记录写入错误。
+func (w *Writer) setErr(err error) {
+	if err == nil {
+		return
+	}
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+// write 写入一行，必要时先轮转文件。
+func (w *Writer) write(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if w.shouldRotate(int64(len(line))) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if w.opts.Sync {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// shouldRotate 判断写入 n 字节前是否需要轮转。
+func (w *Writer) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	return w.opts.MaxAge > 0 && time.Since(w.opened) >= w.opts.MaxAge
+}
+
+// open 以追加方式打开当前文件。
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size, w.opened = f, fi.Size(), time.Now()
+	return nil
+}
+
+// rotate 将当前文件重命名为带时间戳的轮转文件，并打开新文件。
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	rotated := w.path + "." + time.Now().UTC().Format(rotatedLayout)
+	if err := os.Rename(w.path, rotated); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.pending = append(w.pending, rotated)
+	if !w.working {
+		w.working = true
+		w.bg.Add(1)
+		go w.work()
+	}
+	return nil
+}
+
+// work 在后台依次压缩轮转文件并清理旧文件，同一时间只有一个 work 在运行。
+func (w *Writer) work() {
+	defer w.bg.Done()
+	for {
+		w.mu.Lock()
+		batch := w.pending
+		w.pending = nil
+		if len(batch) == 0 {
+			w.working = false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+
+		if w.opts.Compress {
+			for _, path := range batch {
+				w.setErr(compress(path))
+			}
+		}
+		w.setErr(w.prune())
+	}
+}
+
+// compress 将文件压缩为 .gz 并删除原文件。
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// prune 删除超出保留数量的最旧轮转文件，等待压缩的文件计入保留数量但不会被删除。
+func (w *Writer) prune() error {
+	if w.opts.MaxBackups <= 0 {
+		return nil
+	}
+	dir, base := filepath.Split(w.path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, e := range entries {
+		// 压缩尚未完成的文件和它的 .gz 只算作一个轮转文件。
+		if name := strings.TrimSuffix(e.Name(), ".gz"); !e.IsDir() && isRotated(base, name) {
+			backups = append(backups, name)
+		}
+	}
+	slices.Sort(backups)
+	backups = slices.Compact(backups)
+
+	w.mu.Lock()
+	pending := make(map[string]bool, len(w.pending))
+	for _, path := range w.pending {
+		pending[filepath.Base(path)] = true
+	}
+	w.mu.Unlock()
+
+	var errs []error
+	for _, name := range backups[:max(len(backups)-w.opts.MaxBackups, 0)] {
+		if pending[name] {
+			continue
+		}
+		for _, p := range []string{name, name + ".gz"} {
+			if err := os.Remove(filepath.Join(dir, p)); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isRotated 判断 name 是否是 rotate 为 base 生成的轮转文件名。
+func isRotated(base, name string) bool {
+	stamp, ok := strings.CutPrefix(name, base+".")
+	if !ok || len(stamp) != len(rotatedLayout) {
+		return false
+	}
+	_, err := time.Parse(rotatedLayout, stamp)
+	return err == nil
+}
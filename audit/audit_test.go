@@ -0,0 +1,169 @@
+package audit_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+	"github.com/cnk3x/bee/audit"
+)
+
+// backups 返回 dir 中 base 的轮转文件名，按名称排序。
+func backups(t *testing.T, dir, base string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if name := e.Name(); strings.HasPrefix(name, base+".2") {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// countLines 返回文件中的 JSON 行数，.gz 文件先解压。
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r = bufio.NewScanner(f)
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bufio.NewScanner(zr)
+	}
+	n := 0
+	for r.Scan() {
+		var e audit.Entry
+		if err := json.Unmarshal(r.Bytes(), &e); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		n++
+	}
+	return n
+}
+
+func task(i int64) bee.TaskInfo {
+	now := time.Now()
+	return bee.TaskInfo{Pool: "test", Index: i, Submitted: now, Started: now, Finished: now}
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	w, err := audit.New(path, audit.Options{MaxSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		w.TaskDone(task(int64(i)))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	names := backups(t, dir, "audit.log")
+	if len(names) != 4 {
+		t.Fatalf("rotated files = %v, want 4", names)
+	}
+	for _, name := range names {
+		if n := countLines(t, filepath.Join(dir, name)); n != 1 {
+			t.Fatalf("%s has %d lines, want 1", name, n)
+		}
+	}
+	if n := countLines(t, path); n != 1 {
+		t.Fatalf("current file has %d lines, want 1", n)
+	}
+}
+
+func TestRotateByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	w, err := audit.New(path, audit.Options{MaxAge: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.TaskDone(task(1))
+	w.TaskDone(task(2))
+	if names := backups(t, dir, "audit.log"); len(names) != 0 {
+		t.Fatalf("rotated before MaxAge: %v", names)
+	}
+	time.Sleep(30 * time.Millisecond)
+	w.TaskDone(task(3))
+	names := backups(t, dir, "audit.log")
+	if len(names) != 1 {
+		t.Fatalf("rotated files = %v, want 1", names)
+	}
+	if n := countLines(t, filepath.Join(dir, names[0])); n != 2 {
+		t.Fatalf("rotated file has %d lines, want 2", n)
+	}
+}
+
+func TestCompressAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	// 名称相近的其他文件既不计入保留数量也不会被删除。
+	strays := []string{"audit.log.1-manual-backup", "audit.log.keep-me", "audit.log.20261016T000000.000000000.bak"}
+	for _, name := range strays {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("user data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := audit.New(path, audit.Options{MaxSize: 1, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 50 {
+		w.TaskDone(task(int64(i)))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	names := backups(t, dir, "audit.log")
+	var rotated []string
+	for _, name := range names {
+		if !slices.Contains(strays, name) {
+			rotated = append(rotated, name)
+		}
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("rotated files = %v, want 2", rotated)
+	}
+	for _, name := range rotated {
+		if !strings.HasSuffix(name, ".gz") {
+			t.Fatalf("rotated file %s is not compressed", name)
+		}
+		if n := countLines(t, filepath.Join(dir, name)); n != 1 {
+			t.Fatalf("%s has %d lines, want 1", name, n)
+		}
+	}
+	for _, name := range strays {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("unrelated file %s: %v", name, err)
+		}
+	}
+}
@@ -2,42 +2,104 @@ package bee
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// ErrClosed 表示线程池已退出，不再接收新的任务。
+var ErrClosed = errors.New("bee: pool closed")
+
+// ErrBusy 表示任务在 MaxWait 限定的时间内没有获得槽位。
+var ErrBusy = errors.New("bee: pool busy")
+
+// DefaultTimeSlice 是任务调用 Yield 时默认使用的时间片长度。
+const DefaultTimeSlice = 10 * time.Millisecond
+
 // New 创建一个带有缓冲区的Pool，用于管理工作线程。
 //
 // 参数:
 //
 //	ctx context.Context: 上下文，用于控制工作线程的生命周期。
 //	size int: 缓冲区大小，表示可以同时处理的任务数量。
+//	opts ...Option: 可选的线程池配置。
 //
 // 返回值:
 //
 //	*Pool: 指向新创建的Pool实例的指针。
-func New(ctx context.Context, size int) *Pool {
+func New(ctx context.Context, size int, opts ...Option) *Pool {
 	// 创建一个用于通知所有任务完成的通道。
 	done := make(chan struct{})
 	// 创建一个只执行一次的函数，用于关闭done通道。
 	closeDone := sync.OnceFunc(func() { close(done) })
 	// 返回一个新的Pool实例。
-	return &Pool{
+	p := &Pool{
 		ctx:       ctx,
-		work:      make(chan struct{}, size),
+		size:      size,
 		done:      done,
 		closeDone: closeDone,
+		timeSlice: DefaultTimeSlice,
+		waiting:   NewPriorityScheduler(),
+		state:     StateRunning,
+		active:    map[*task]struct{}{},
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.mu.Lock()
+	p.armIdleLocked()
+	p.mu.Unlock()
+	p.startCapacity()
+	p.startPressure()
+	return p
+}
+
+// Option 定义了线程池的可选配置。
+type Option func(*Pool)
+
+// WithTimeSlice 设置任务的时间片长度。
+//
+// 任务在时间片用完之前调用 Yield 不会让出槽位。
+//
+// 参数:
+//
+//	d time.Duration: 时间片长度。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithTimeSlice(d time.Duration) Option {
+	return func(p *Pool) { p.timeSlice = d }
 }
 
 // Pool 定义了一个线程池结构体，用于管理工作线程。
 type Pool struct {
 	ctx       context.Context    // 上下文，用于控制工作线程的生命周期
-	work      chan struct{}      // 用于限制并发任务数的通道
+	name      string             // 线程池名称
+	size      int                // 可以同时运行的任务数量
+	paused    bool               // 是否暂停分配槽位
 	done      chan struct{}      // 用于通知所有任务完成的通道
 	closeDone context.CancelFunc // 用于关闭done通道的函数
+	timeSlice time.Duration      // 任务让出槽位前可以连续运行的时间
+	mu        sync.Mutex         // 保护等待队列和槽位分配
+	waiting   Scheduler          // 等待槽位的任务队列
+	queued    map[*task]struct{} // 在等待队列中的任务，包括 head
+	head      *task              // 已从调度器取出、因描述符不足尚未启动的任务
+	seq       uint64             // 任务的入队序号
+	unique    map[string]*task   // 带唯一键的排队中或运行中的任务
+	state     State              // 线程池的运行状态
+	idle      idleConfig         // 空闲自动停止的配置和计时器
+	capacity  capacityConfig     // 按时间段调整容量的计划
+	pressure  pressureState      // 按 PSI 压力限流的配置和状态
+	fd        fdBudget           // 按文件描述符准入的配置和状态
+	observers []Observer         // 任务结束时通知的观察者
+	active    map[*task]struct{} // 已开始运行且尚未结束的任务，包括让出槽位的任务
+	drained   []chan struct{}    // active 归零时关闭，用于等待任务结束
 	running   atomic.Int32       // 当前正在运行的任务数量
 	worked    atomic.Int64       // 已完成的任务数量
+	rejected  atomic.Int64       // 未能提交的任务数量
+	panics    atomic.Int64       // 发生 panic 的任务数量
 	index     atomic.Int64       // 任务的索引计数器
 }
 
@@ -77,25 +139,254 @@ func (p *Pool) RunWithContext(f func(context.Context)) bool {
 //
 //	bool: 表示任务是否已提交到线程池中执行。
 func (p *Pool) RunWithContextAndIndex(f func(ctx context.Context, index int64)) bool {
+	return p.Submit(f) == nil
+}
+
+// Submit 按照任务选项提交一个任务。
+//
+// 没有空闲槽位时，Submit 会阻塞到任务获得槽位或线程池退出。
+// 等待中的任务默认按优先级从高到低、同优先级按提交顺序获得槽位，可以通过 WithScheduler 修改。
+//
+// 参数:
+//
+//	f func(ctx context.Context, index int64): 要执行的任务函数，接受上下文和索引作为参数。
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	error: 任务未能提交时返回的错误。
+func (p *Pool) Submit(f func(ctx context.Context, index int64), opts ...TaskOption) error {
+	return p.SubmitContext(context.Background(), f, opts...)
+}
+
+// SubmitContext 与 Submit 相同，但 ctx 取消时停止等待槽位。
+//
+// ctx 只控制等待槽位的过程，任务运行时收到的仍是线程池的上下文。
+// ctx 通过 WithFanout 附加了并发上限时，任务在占用名额之后才开始等待槽位。
+//
+// 参数:
+//
+//	ctx context.Context: 控制等待过程的上下文。
+//	f func(ctx context.Context, index int64): 要执行的任务函数，接受上下文和索引作为参数。
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	error: 任务未能提交时返回的错误，ctx 取消时返回 ctx 的错误。
+func (p *Pool) SubmitContext(ctx context.Context, f func(ctx context.Context, index int64), opts ...TaskOption) error {
+	t := &task{pool: p, f: f, maxWait: -1, submitted: time.Now()}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if err := t.plan(ctx); err != nil {
+		p.rejected.Add(1)
+		return err
+	}
+	if err := p.checkFDs(t); err != nil {
+		p.rejected.Add(1)
+		return err
+	}
+	if err := p.acquireFanout(ctx, t); err != nil {
+		p.rejected.Add(1)
+		return err
+	}
+	if err := p.acquire(ctx, t); err != nil {
+		t.fanout.release()
+		if err == errMerged {
+			t.dropped(ErrReplaced)
+			return nil
+		}
+		p.rejected.Add(1)
+		return err
+	}
+	go p.exec(t)
+	return nil
+}
+
+// acquire 为任务获取一个槽位。
+func (p *Pool) acquire(ctx context.Context, t *task) error {
 	select {
 	case <-p.ctx.Done():
 		p.closeDone()
-		return false
+		return ErrClosed
 	case <-p.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	p.mu.Lock()
+	if err := p.dedupeLocked(t); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	woke := p.wakeLocked()
+	if len(p.queued) == 0 && p.freeLocked() && p.fdsOKLocked(t) {
+		p.grantLocked(t)
+		p.mu.Unlock()
+		p.notifyState(woke)
+		return nil
+	}
+	if t.maxWait == 0 {
+		p.forgetLocked(t)
+		p.armIdleLocked()
+		p.mu.Unlock()
+		p.notifyState(woke)
+		return ErrBusy
+	}
+	p.pushLocked(t)
+	p.dispatchLocked()
+	p.mu.Unlock()
+	p.notifyState(woke)
+
+	var expired <-chan time.Time
+	if t.maxWait > 0 {
+		timer := time.NewTimer(t.maxWait)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	err := ErrClosed
+	select {
+	case <-t.ready:
+		return nil
+	case <-p.ctx.Done():
+		p.closeDone()
+	case <-p.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-expired:
+		err = ErrBusy
+	}
+	if p.abandon(t) {
+		return err
+	}
+	// 放弃等待前已经拿到了槽位，任务照常执行。
+	return nil
+}
+
+// abandon 将仍在排队的任务移出等待队列，返回任务是否确实被移除。
+func (p *Pool) abandon(t *task) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.queued[t]; !ok {
 		return false
-	case p.work <- struct{}{}:
-		p.running.Add(1)
-		go func() {
-			defer func() {
-				<-p.work
-				p.running.Add(-1)
-				p.worked.Add(1)
-			}()
-			defer recover()
-			f(p.ctx, p.index.Add(1))
-		}()
-		return true
 	}
+	if p.head == t {
+		p.head = nil
+		p.dispatchLocked()
+	} else {
+		p.waiting.Remove(&t.ticket)
+	}
+	delete(p.queued, t)
+	p.forgetLocked(t)
+	p.armIdleLocked()
+	return true
+}
+
+// exec 在独立的协程中运行任务，并在结束后归还槽位。
+func (p *Pool) exec(t *task) {
+	defer p.finish(t)
+	defer func() {
+		if r := recover(); r != nil {
+			p.panics.Add(1)
+			t.err = &PanicError{Value: r}
+		}
+	}()
+	ctx, cancel := p.taskContext(t)
+	defer cancel()
+	if t.expect > 0 && !HasBudget(ctx, t.expect) {
+		// 排队期间预算已经不够，不再启动任务。
+		t.err = ErrBudgetExceeded
+		t.dropped(ErrBudgetExceeded)
+		return
+	}
+	t.sliceStart = t.startedAt
+	t.f(ctx, t.index)
+}
+
+// finish 归还任务占用的槽位，并唤醒等待中的任务。
+func (p *Pool) finish(t *task) {
+	p.mu.Lock()
+	p.forgetLocked(t)
+	if t.holding {
+		t.holding = false
+		p.running.Add(-1)
+		p.dispatchLocked()
+	}
+	p.mu.Unlock()
+	t.fanout.release()
+	p.worked.Add(1)
+	// 观察者收到通知之后任务才算结束，Shutdown 返回时审计等记录已经写完。
+	p.observe(t)
+
+	p.mu.Lock()
+	if p.fd.held -= t.fds; p.fd.held == 0 && t.fds > 0 {
+		// 重新记录没有任务持有描述符时的打开数量。
+		p.fd.checked = time.Time{}
+	}
+	delete(p.active, t)
+	if len(p.active) == 0 {
+		for _, ch := range p.drained {
+			close(ch)
+		}
+		p.drained = nil
+	}
+	p.armIdleLocked()
+	p.mu.Unlock()
+}
+
+// pushLocked 将任务加入等待队列，调用方需持有 p.mu。
+func (p *Pool) pushLocked(t *task) {
+	p.seq++
+	t.ticket = Ticket{Seq: p.seq, Priority: t.priority, Name: t.name, Tenant: t.tenant, Submitted: t.submitted, task: t}
+	t.ready = make(chan struct{})
+	if p.queued == nil {
+		p.queued = map[*task]struct{}{}
+	}
+	p.queued[t] = struct{}{}
+	p.waiting.Push(&t.ticket)
+}
+
+// dispatchLocked 将空闲槽位分配给等待队列中的任务，调用方需持有 p.mu。
+func (p *Pool) dispatchLocked() {
+	for p.freeLocked() {
+		t := p.head
+		if t == nil {
+			if p.waiting.Len() == 0 {
+				return
+			}
+			t = p.waiting.Pop().task
+		}
+		if !p.fdsOKLocked(t) {
+			p.head = t
+			p.retryFDsLocked()
+			return
+		}
+		p.head = nil
+		delete(p.queued, t)
+		p.grantLocked(t)
+		close(t.ready)
+	}
+}
+
+// grantLocked 为任务分配一个槽位，调用方需持有 p.mu。
+func (p *Pool) grantLocked(t *task) {
+	p.running.Add(1)
+	t.holding = true
+	if !t.started {
+		t.started = true
+		t.index = p.index.Add(1)
+		t.startedAt = time.Now()
+		p.fd.held += t.fds
+		p.active[t] = struct{}{}
+	}
+}
+
+// freeLocked 判断是否还有空闲槽位，调用方需持有 p.mu。
+func (p *Pool) freeLocked() bool {
+	return !p.paused && int(p.running.Load()) < p.limitLocked()
 }
 
 // Exit 启动线程池的退出过程。
@@ -103,6 +394,36 @@ func (p *Pool) Exit() {
 	p.closeDone()
 }
 
+// Shutdown 退出线程池，并等待已开始运行的任务全部结束。
+//
+// 仍在等待槽位的提交会返回 ErrClosed，已让出槽位的任务会继续排队直到运行结束。
+//
+// 参数:
+//
+//	ctx context.Context: 等待的期限。
+//
+// 返回值:
+//
+//	error: ctx 先于任务结束时返回 ctx 的错误。
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.closeDone()
+	p.mu.Lock()
+	if len(p.active) == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	p.drained = append(p.drained, ch)
+	p.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Worked 返回已完成任务的数量。
 //
 // 返回值:
@@ -0,0 +1,15 @@
+package bee_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cnk3x/bee"
+	"github.com/cnk3x/bee/beetest"
+)
+
+func TestConformance(t *testing.T) {
+	beetest.RunConformance(t, func(ctx context.Context, size int) beetest.Pool {
+		return bee.New(ctx, size)
+	})
+}
@@ -0,0 +1,266 @@
+package beetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// Pool 是 RunConformance 检查的执行器，*bee.Pool 实现了该接口。
+type Pool interface {
+	bee.Executor
+	// Running 返回当前正在运行的任务数量。
+	Running() int32
+	// Worked 返回已完成的任务数量，包括发生 panic 的任务。
+	Worked() int64
+	// Exit 停止接收新的任务。
+	Exit()
+}
+
+// Factory 创建一个容量为 size、生命周期受 ctx 控制的执行器。
+type Factory func(ctx context.Context, size int) Pool
+
+// settle 是等待计数器稳定的最长时间。
+const settle = 5 * time.Second
+
+// RunConformance 检查执行器是否满足 bee.Pool 的行为约定，应配合 -race 运行。
+//
+// 套件检查：同时运行的任务数量不超过容量；每个被接受的任务恰好运行一次；
+// Running 和 Worked 与实际运行的任务一致；Exit、创建时的 ctx 取消以及提交时的 ctx 取消都会停止接收任务；
+// 任务 panic 不会影响执行器和计数器。
+//
+// 参数:
+//
+//	t *testing.T: 测试上下文。
+//	factory Factory: 创建执行器的函数，每个子测试使用一个新的执行器。
+func RunConformance(t *testing.T, factory Factory) {
+	t.Run("Limit", func(t *testing.T) { testLimit(t, factory) })
+	t.Run("ExactlyOnce", func(t *testing.T) { testExactlyOnce(t, factory) })
+	t.Run("Exit", func(t *testing.T) { testExit(t, factory) })
+	t.Run("PoolContext", func(t *testing.T) { testPoolContext(t, factory) })
+	t.Run("SubmitContext", func(t *testing.T) { testSubmitContext(t, factory) })
+	t.Run("Panic", func(t *testing.T) { testPanic(t, factory) })
+}
+
+// newPool 创建执行器，并在测试结束时让它退出。
+func newPool(t *testing.T, factory Factory, size int) (Pool, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := factory(ctx, size)
+	t.Cleanup(func() {
+		p.Exit()
+		cancel()
+	})
+	return p, cancel
+}
+
+// eventually 等待 cond 成立，超时后报告错误。
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(settle)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// fill 提交 size 个阻塞到 release 关闭的任务，并等待它们全部开始运行。
+func fill(t *testing.T, p Pool, size int, release <-chan struct{}) {
+	t.Helper()
+	var started sync.WaitGroup
+	for range size {
+		started.Add(1)
+		err := p.SubmitContext(context.Background(), func(context.Context, int64) {
+			started.Done()
+			<-release
+		})
+		if err != nil {
+			t.Fatalf("SubmitContext() to idle pool = %v", err)
+		}
+	}
+	started.Wait()
+}
+
+func testLimit(t *testing.T, factory Factory) {
+	const size, tasks = 4, 200
+	p, _ := newPool(t, factory, size)
+	var (
+		wg      sync.WaitGroup
+		running atomic.Int32
+		peak    atomic.Int32
+		over    atomic.Int32
+	)
+	for range tasks {
+		wg.Add(1)
+		go func() {
+			err := p.SubmitContext(context.Background(), func(context.Context, int64) {
+				defer wg.Done()
+				track(&peak, running.Add(1))
+				if n := p.Running(); n < 1 || n > size {
+					over.Store(n)
+				}
+				time.Sleep(100 * time.Microsecond)
+				running.Add(-1)
+			})
+			if err != nil {
+				wg.Done()
+				t.Errorf("SubmitContext() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := peak.Load(); n > size {
+		t.Fatalf("%d tasks ran at once, size is %d", n, size)
+	}
+	if n := over.Load(); n != 0 {
+		t.Fatalf("Running() = %d inside a task, want 1..%d", n, size)
+	}
+}
+
+func testExactlyOnce(t *testing.T, factory Factory) {
+	const size, tasks = 3, 300
+	p, _ := newPool(t, factory, size)
+	var (
+		wg       sync.WaitGroup
+		runs     [tasks]atomic.Int32
+		accepted atomic.Int64
+	)
+	for i := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 一部分提交在等待中途取消，被拒绝的任务不应运行。
+			ctx := context.Background()
+			if i%5 == 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(i%7)*100*time.Microsecond)
+				defer cancel()
+			}
+			if err := p.SubmitContext(ctx, func(context.Context, int64) { runs[i].Add(1) }); err == nil {
+				accepted.Add(1)
+				return
+			}
+			runs[i].Add(-100)
+		}()
+	}
+	wg.Wait()
+	eventually(t, "Worked() to match accepted tasks", func() bool { return p.Worked() == accepted.Load() })
+	eventually(t, "Running() to drop to 0", func() bool { return p.Running() == 0 })
+
+	time.Sleep(10 * time.Millisecond)
+	for i := range runs {
+		switch n := runs[i].Load(); n {
+		case 1, -100:
+		case -99:
+			t.Fatalf("task %d ran although SubmitContext returned an error", i)
+		default:
+			t.Fatalf("task %d ran %d times, want 1", i, n)
+		}
+	}
+	if w, a := p.Worked(), accepted.Load(); w != a {
+		t.Fatalf("Worked() = %d, accepted %d tasks", w, a)
+	}
+}
+
+func testExit(t *testing.T, factory Factory) {
+	const size = 2
+	p, _ := newPool(t, factory, size)
+	release := make(chan struct{})
+	fill(t, p, size, release)
+
+	// 等待槽位的提交在 Exit 后返回错误。
+	var ran atomic.Bool
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- p.SubmitContext(context.Background(), func(context.Context, int64) { ran.Store(true) })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	p.Exit()
+	select {
+	case err := <-blocked:
+		if err == nil {
+			t.Fatal("SubmitContext() waiting for a slot = nil after Exit")
+		}
+	case <-time.After(settle):
+		t.Fatal("SubmitContext() still waiting after Exit")
+	}
+
+	close(release)
+	eventually(t, "Running() to drop to 0", func() bool { return p.Running() == 0 })
+	if err := p.SubmitContext(context.Background(), func(context.Context, int64) { ran.Store(true) }); err == nil {
+		t.Fatal("SubmitContext() after Exit = nil")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if ran.Load() {
+		t.Fatal("task rejected after Exit ran")
+	}
+	if n := p.Worked(); n != size {
+		t.Fatalf("Worked() = %d, want %d", n, size)
+	}
+}
+
+func testPoolContext(t *testing.T, factory Factory) {
+	p, cancel := newPool(t, factory, 1)
+	cancel()
+	var ran atomic.Bool
+	if err := p.SubmitContext(context.Background(), func(context.Context, int64) { ran.Store(true) }); err == nil {
+		t.Fatal("SubmitContext() after the pool context was canceled = nil")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if ran.Load() {
+		t.Fatal("task rejected after the pool context was canceled ran")
+	}
+}
+
+func testSubmitContext(t *testing.T, factory Factory) {
+	const size = 2
+	p, _ := newPool(t, factory, size)
+	release := make(chan struct{})
+	defer close(release)
+	fill(t, p, size, release)
+
+	var ran atomic.Bool
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.SubmitContext(ctx, func(context.Context, int64) { ran.Store(true) }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SubmitContext() with canceled context = %v, want context.Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.SubmitContext(ctx, func(context.Context, int64) { ran.Store(true) }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SubmitContext() to full pool = %v, want context.DeadlineExceeded", err)
+	}
+	if ran.Load() {
+		t.Fatal("task rejected by context cancellation ran")
+	}
+	if n := p.Running(); n != size {
+		t.Fatalf("Running() = %d, want %d", n, size)
+	}
+}
+
+func testPanic(t *testing.T, factory Factory) {
+	const size, tasks = 2, 20
+	p, _ := newPool(t, factory, size)
+	var done atomic.Int32
+	for i := range tasks {
+		err := p.SubmitContext(context.Background(), func(context.Context, int64) {
+			defer done.Add(1)
+			if i%2 == 0 {
+				panic("beetest: task panic")
+			}
+		})
+		if err != nil {
+			t.Fatalf("SubmitContext() after a task panicked = %v", err)
+		}
+	}
+	eventually(t, "tasks to finish", func() bool { return done.Load() == tasks })
+	eventually(t, "Worked() to count panicking tasks", func() bool { return p.Worked() == tasks })
+	eventually(t, "Running() to drop to 0", func() bool { return p.Running() == 0 })
+}
@@ -0,0 +1,269 @@
+// Package beetest 提供验证 bee 扩展实现的测试套件。
+package beetest
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cnk3x/bee"
+)
+
+// RunSchedulerConformance 检查调度器是否满足 bee.Scheduler 的约定。
+//
+// 套件只检查与排序策略无关的行为：每个加入的任务恰好被取出或移除一次、Len 与队列内容一致、
+// 同一个凭证取出后可以重新加入、实现了 bee.Peeker 时 Peek 与下一次 Pop 一致，
+// 以及调度器在线程池中使用时每个任务恰好运行一次且并发不超过容量。
+//
+// 参数:
+//
+//	t *testing.T: 测试上下文。
+//	newScheduler func() bee.Scheduler: 创建一个新的空调度器。
+func RunSchedulerConformance(t *testing.T, newScheduler func() bee.Scheduler) {
+	t.Run("Empty", func(t *testing.T) { testEmpty(t, newScheduler()) })
+	t.Run("PushPop", func(t *testing.T) { testPushPop(t, newScheduler()) })
+	t.Run("Remove", func(t *testing.T) { testRemove(t, newScheduler()) })
+	t.Run("Requeue", func(t *testing.T) { testRequeue(t, newScheduler()) })
+	t.Run("Peek", func(t *testing.T) { testPeek(t, newScheduler()) })
+	t.Run("Random", func(t *testing.T) { testRandom(t, newScheduler()) })
+	t.Run("Pool", func(t *testing.T) { testPool(t, newScheduler()) })
+}
+
+// ticketGen 生成入队序号递增、优先级和租户各不相同的凭证。
+type ticketGen struct {
+	seq uint64
+}
+
+func (g *ticketGen) next() *bee.Ticket {
+	g.seq++
+	return &bee.Ticket{
+		Seq:      g.seq,
+		Priority: int(g.seq % 5),
+		Name:     fmt.Sprintf("task-%d", g.seq),
+		Tenant:   fmt.Sprintf("tenant-%d", g.seq%3),
+	}
+}
+
+func testEmpty(t *testing.T, s bee.Scheduler) {
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Len() of new scheduler = %d, want 0", n)
+	}
+	if tk := s.Pop(); tk != nil {
+		t.Fatalf("Pop() of empty scheduler = %+v, want nil", tk)
+	}
+	var g ticketGen
+	if s.Remove(g.next()) {
+		t.Fatal("Remove() of unknown ticket = true, want false")
+	}
+	if pk, ok := s.(bee.Peeker); ok {
+		if tk := pk.Peek(); tk != nil {
+			t.Fatalf("Peek() of empty scheduler = %+v, want nil", tk)
+		}
+	}
+}
+
+func testPushPop(t *testing.T, s bee.Scheduler) {
+	var g ticketGen
+	pushed := map[*bee.Ticket]bool{}
+	for i := range 100 {
+		tk := g.next()
+		pushed[tk] = true
+		s.Push(tk)
+		if n := s.Len(); n != i+1 {
+			t.Fatalf("Len() after %d pushes = %d", i+1, n)
+		}
+	}
+	for i := range 100 {
+		tk := s.Pop()
+		if tk == nil {
+			t.Fatalf("Pop() #%d = nil with %d tickets queued", i+1, 100-i)
+		}
+		if !pushed[tk] {
+			t.Fatalf("Pop() returned ticket %d that is not queued", tk.Seq)
+		}
+		delete(pushed, tk)
+		if n := s.Len(); n != 99-i {
+			t.Fatalf("Len() after %d pops = %d, want %d", i+1, n, 99-i)
+		}
+	}
+	if tk := s.Pop(); tk != nil {
+		t.Fatalf("Pop() of drained scheduler = %+v, want nil", tk)
+	}
+}
+
+func testRemove(t *testing.T, s bee.Scheduler) {
+	var g ticketGen
+	var all []*bee.Ticket
+	for range 50 {
+		tk := g.next()
+		all = append(all, tk)
+		s.Push(tk)
+	}
+	removed := map[*bee.Ticket]bool{}
+	for i := 0; i < len(all); i += 3 {
+		if !s.Remove(all[i]) {
+			t.Fatalf("Remove() of queued ticket %d = false", all[i].Seq)
+		}
+		if s.Remove(all[i]) {
+			t.Fatalf("second Remove() of ticket %d = true", all[i].Seq)
+		}
+		removed[all[i]] = true
+	}
+	if n, want := s.Len(), len(all)-len(removed); n != want {
+		t.Fatalf("Len() after removals = %d, want %d", n, want)
+	}
+	seen := map[*bee.Ticket]bool{}
+	for tk := s.Pop(); tk != nil; tk = s.Pop() {
+		if removed[tk] {
+			t.Fatalf("Pop() returned removed ticket %d", tk.Seq)
+		}
+		if seen[tk] {
+			t.Fatalf("Pop() returned ticket %d twice", tk.Seq)
+		}
+		seen[tk] = true
+	}
+	if want := len(all) - len(removed); len(seen) != want {
+		t.Fatalf("popped %d tickets, want %d", len(seen), want)
+	}
+	if s.Remove(all[1]) {
+		t.Fatal("Remove() of popped ticket = true, want false")
+	}
+}
+
+func testRequeue(t *testing.T, s bee.Scheduler) {
+	var g ticketGen
+	for range 10 {
+		s.Push(g.next())
+	}
+	// 线程池在任务让出槽位时会更新同一个凭证并重新加入。
+	for range 30 {
+		tk := s.Pop()
+		if tk == nil {
+			t.Fatal("Pop() = nil while tickets are queued")
+		}
+		g.seq++
+		tk.Seq = g.seq
+		s.Push(tk)
+		if n := s.Len(); n != 10 {
+			t.Fatalf("Len() after requeue = %d, want 10", n)
+		}
+	}
+	for range 10 {
+		if s.Pop() == nil {
+			t.Fatal("Pop() = nil while tickets are queued")
+		}
+	}
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Len() after draining = %d, want 0", n)
+	}
+}
+
+func testPeek(t *testing.T, s bee.Scheduler) {
+	pk, ok := s.(bee.Peeker)
+	if !ok {
+		t.Skip("scheduler does not implement bee.Peeker")
+	}
+	var g ticketGen
+	for range 40 {
+		s.Push(g.next())
+	}
+	for s.Len() > 0 {
+		want := pk.Peek()
+		if n := s.Len(); pk.Peek() != want || s.Len() != n {
+			t.Fatal("Peek() changed the scheduler")
+		}
+		if got := s.Pop(); got != want {
+			t.Fatalf("Pop() = ticket %d, Peek() returned ticket %d", got.Seq, want.Seq)
+		}
+	}
+}
+
+func testRandom(t *testing.T, s bee.Scheduler) {
+	var g ticketGen
+	r := rand.New(rand.NewPCG(1, 2))
+	queued := map[*bee.Ticket]bool{}
+	var known []*bee.Ticket
+	for i := range 5000 {
+		switch op := r.IntN(10); {
+		case op < 5:
+			tk := g.next()
+			known = append(known, tk)
+			queued[tk] = true
+			s.Push(tk)
+		case op < 8:
+			tk := s.Pop()
+			if (tk == nil) != (len(queued) == 0) {
+				t.Fatalf("op %d: Pop() = %v with %d tickets queued", i, tk, len(queued))
+			}
+			if tk != nil && !queued[tk] {
+				t.Fatalf("op %d: Pop() returned ticket %d that is not queued", i, tk.Seq)
+			}
+			delete(queued, tk)
+		default:
+			if len(known) == 0 {
+				continue
+			}
+			tk := known[r.IntN(len(known))]
+			if got := s.Remove(tk); got != queued[tk] {
+				t.Fatalf("op %d: Remove(ticket %d) = %v, want %v", i, tk.Seq, got, queued[tk])
+			}
+			delete(queued, tk)
+		}
+		if n := s.Len(); n != len(queued) {
+			t.Fatalf("op %d: Len() = %d, want %d", i, n, len(queued))
+		}
+	}
+}
+
+func testPool(t *testing.T, s bee.Scheduler) {
+	const size, tasks = 3, 200
+	p := bee.New(context.Background(), size, bee.WithScheduler(s))
+	var (
+		wg      sync.WaitGroup
+		running atomic.Int32
+		peak    atomic.Int32
+		runs    [tasks]atomic.Int32
+	)
+	for i := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Submit(func(ctx context.Context, _ int64) {
+				track(&peak, running.Add(1))
+				runs[i].Add(1)
+				bee.Yield(ctx)
+				running.Add(-1)
+			}, bee.Priority(i%4), bee.Tenant(fmt.Sprintf("tenant-%d", i%3)))
+			if err != nil {
+				t.Errorf("Submit() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i := range runs {
+		if n := runs[i].Load(); n != 1 {
+			t.Fatalf("task %d ran %d times, want 1", i, n)
+		}
+	}
+	if n := peak.Load(); n > size {
+		t.Fatalf("%d tasks ran at once, pool size is %d", n, size)
+	}
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Len() after shutdown = %d, want 0", n)
+	}
+}
+
+// track 把 peak 更新为观察到的最大值。
+func track(peak *atomic.Int32, n int32) {
+	for m := peak.Load(); n > m; m = peak.Load() {
+		if peak.CompareAndSwap(m, n) {
+			return
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package bee
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBudgetExceeded 表示上下文的剩余时间不足以完成任务，任务未被启动。
+var ErrBudgetExceeded = errors.New("bee: deadline budget exceeded")
+
+// Remaining 返回上下文截止之前的剩余时间。
+//
+// 参数:
+//
+//	ctx context.Context: 上下文。
+//
+// 返回值:
+//
+//	time.Duration: 剩余时间，已经截止时为 0。
+//	bool: 上下文是否有截止时间。
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(deadline), 0), true
+}
+
+// HasBudget 判断上下文的剩余时间是否足以完成预计耗时为 expected 的工作。
+//
+// 没有截止时间的上下文总是有足够的预算，已取消的上下文总是没有。
+//
+// 参数:
+//
+//	ctx context.Context: 上下文。
+//	expected time.Duration: 预计耗时。
+//
+// 返回值:
+//
+//	bool: 剩余时间是否足够。
+func HasBudget(ctx context.Context, expected time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	left, ok := Remaining(ctx)
+	return !ok || left >= expected
+}
+
+// WithBudgetFraction 派生一个只能使用剩余时间 frac 比例的子上下文。
+//
+// 例如剩余 500ms 时，frac 为 0.8 的子上下文在 400ms 后截止，余下的时间留给上层汇总结果。
+// 上下文没有截止时间时返回一个不改变截止时间的子上下文。
+//
+// 参数:
+//
+//	ctx context.Context: 父上下文。
+//	frac float64: 子上下文可用的比例，取值为 (0, 1]。
+//
+// 返回值:
+//
+//	context.Context: 子上下文。
+//	context.CancelFunc: 释放子上下文的函数。
+func WithBudgetFraction(ctx context.Context, frac float64) (context.Context, context.CancelFunc) {
+	deadline, ok := fractionDeadline(ctx, frac)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// WithBudgetReserve 派生一个比父上下文提前 margin 截止的子上下文，为上层汇总结果预留时间。
+//
+// 上下文没有截止时间时返回一个不改变截止时间的子上下文。
+//
+// 参数:
+//
+//	ctx context.Context: 父上下文。
+//	margin time.Duration: 预留的时间。
+//
+// 返回值:
+//
+//	context.Context: 子上下文。
+//	context.CancelFunc: 释放子上下文的函数。
+func WithBudgetReserve(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-margin))
+}
+
+// fractionDeadline 计算使用剩余时间 frac 比例的截止时间。
+func fractionDeadline(ctx context.Context, frac float64) (time.Time, bool) {
+	left, ok := Remaining(ctx)
+	if !ok {
+		return time.Time{}, false
+	}
+	frac = min(max(frac, 0), 1)
+	return time.Now().Add(time.Duration(float64(left) * frac)), true
+}
+
+// Inherit 让任务的上下文继承提交时传给 SubmitContext 的上下文，包括其中的值、截止时间和取消信号。
+//
+// 线程池退出时任务的上下文同样会被取消。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Inherit() TaskOption {
+	return func(t *task) { t.inherit = true }
+}
+
+// BudgetFraction 让任务继承提交时的上下文，并且只使用提交时剩余时间的 frac 比例。
+//
+// 参数:
+//
+//	frac float64: 任务可用的比例，取值为 (0, 1]。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func BudgetFraction(frac float64) TaskOption {
+	return func(t *task) { t.inherit, t.fraction = true, frac }
+}
+
+// BudgetReserve 让任务继承提交时的上下文，并比它提前 margin 截止。
+//
+// 参数:
+//
+//	margin time.Duration: 为上层汇总结果预留的时间。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func BudgetReserve(margin time.Duration) TaskOption {
+	return func(t *task) { t.inherit, t.reserve = true, margin }
+}
+
+// Expect 声明任务的预计耗时。
+//
+// 提交时剩余时间不足，SubmitContext 返回 ErrBudgetExceeded；排队之后剩余时间不足，
+// 任务函数不会被调用，观察者收到的 TaskInfo.Err 为 ErrBudgetExceeded，
+// Dispatcher 的 Future 和 MapResumable 也返回 ErrBudgetExceeded。
+// 剩余时间按任务上下文计算，未使用 Inherit 等选项时为线程池的上下文。
+//
+// 参数:
+//
+//	d time.Duration: 预计耗时。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Expect(d time.Duration) TaskOption {
+	return func(t *task) { t.expect = d }
+}
+
+// plan 在提交时根据提交上下文计算任务的截止时间，并检查剩余时间是否足够。
+func (t *task) plan(ctx context.Context) error {
+	if !t.inherit {
+		if t.expect > 0 && !HasBudget(t.pool.ctx, t.expect) {
+			return ErrBudgetExceeded
+		}
+		return nil
+	}
+
+	t.parent = ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		t.deadline = deadline
+	}
+	if t.fraction > 0 {
+		t.deadline, _ = fractionDeadline(ctx, t.fraction)
+	}
+	if t.reserve > 0 && !t.deadline.IsZero() {
+		t.deadline = t.deadline.Add(-t.reserve)
+	}
+	if t.expect > 0 && (ctx.Err() != nil || !t.deadline.IsZero() && time.Until(t.deadline) < t.expect) {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// taskContext 返回任务运行时使用的上下文。
+func (p *Pool) taskContext(t *task) (context.Context, context.CancelFunc) {
+	if t.parent == nil {
+		return context.WithValue(p.ctx, taskKey{}, t), func() {}
+	}
+	ctx, cancel := context.WithCancel(t.parent)
+	stop := context.AfterFunc(p.ctx, cancel)
+	if !t.deadline.IsZero() {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithDeadline(ctx, t.deadline)
+		prev := cancel
+		cancel = func() { cancelDeadline(); prev() }
+	}
+	return context.WithValue(ctx, taskKey{}, t), func() {
+		stop()
+		cancel()
+	}
+}
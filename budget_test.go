@@ -0,0 +1,52 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestExpectDropCompletesFuture(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	d := bee.NewDispatcher(ctx, 1, func(ctx context.Context, in time.Duration) (int, error) {
+		time.Sleep(in)
+		return 1, nil
+	})
+	first := d.Submit(200 * time.Millisecond)
+	second := d.Submit(0, bee.Expect(200*time.Millisecond))
+
+	wait, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if _, err := first.Wait(wait); err != nil {
+		t.Fatalf("first Wait() = %v", err)
+	}
+	if _, err := second.Wait(wait); !errors.Is(err, bee.ErrBudgetExceeded) {
+		t.Fatalf("second Wait() = %v, want ErrBudgetExceeded", err)
+	}
+}
+
+func TestExpectDropFailsMap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	p := bee.New(context.Background(), 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- bee.MapResumable(ctx, p, 3, filepath.Join(t.TempDir(), "cp"), func(ctx context.Context, i int) error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		}, bee.MapTaskOptions(bee.Expect(150*time.Millisecond)))
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, bee.ErrBudgetExceeded) {
+			t.Fatalf("MapResumable() = %v, want ErrBudgetExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("MapResumable() did not return")
+	}
+}
@@ -0,0 +1,160 @@
+package bee
+
+import (
+	"slices"
+	"time"
+)
+
+// CapacityRule 是按时间段设置线程池容量的规则。
+//
+// From 和 To 是距当天零点的时长，From 大于 To 时表示跨越午夜，例如 22 点到次日 6 点；
+// 两者相等时表示全天。
+type CapacityRule struct {
+	Name string         // 规则名称，显示在 Stats 中
+	From time.Duration  // 开始时间
+	To   time.Duration  // 结束时间，不包含
+	Days []time.Weekday // 生效的日期，按时间段开始的那一天计算，为空时每天生效
+	Size int            // 规则生效期间的容量
+}
+
+// active 判断规则在 now 所在的时区是否生效。
+func (r CapacityRule) active(now time.Time) bool {
+	tod := sinceMidnight(now)
+	switch {
+	case r.From == r.To:
+		return r.on(now)
+	case r.From < r.To:
+		return r.From <= tod && tod < r.To && r.on(now)
+	default:
+		return (tod >= r.From && r.on(now)) || (tod < r.To && r.on(now.AddDate(0, 0, -1)))
+	}
+}
+
+// on 判断规则在 t 所在的日期是否生效。
+func (r CapacityRule) on(t time.Time) bool {
+	return len(r.Days) == 0 || slices.Contains(r.Days, t.Weekday())
+}
+
+// capacityConfig 是容量计划的配置和计时器状态，由 pool.mu 保护。
+type capacityConfig struct {
+	loc   *time.Location
+	rules []CapacityRule
+	base  int    // 没有规则生效时的容量
+	rule  string // 当前生效的规则名称
+	index int    // 当前生效的规则序号，没有规则生效时为 -1
+	timer *time.Timer
+	gen   uint64 // 计时器代数，用于忽略已失效的计时器
+}
+
+// WithCapacitySchedule 按一天中的时间段自动调整线程池容量。
+//
+// 规则按顺序匹配，第一个生效的规则决定容量，没有规则生效时使用 New 传入的容量。
+// 线程池在规则的开始和结束时间重新匹配，生效的规则改变时调用 Resize；
+// 手动调用 Resize 设置的容量会保持到下一次规则改变。线程池退出后停止调整；
+// 因空闲而停止时暂停计时，恢复运行时重新匹配规则。
+//
+// 参数:
+//
+//	loc *time.Location: 规则使用的时区，为 nil 时使用 time.Local。
+//	rules ...CapacityRule: 容量规则。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithCapacitySchedule(loc *time.Location, rules ...CapacityRule) Option {
+	if loc == nil {
+		loc = time.Local
+	}
+	return func(p *Pool) { p.capacity.loc, p.capacity.rules = loc, rules }
+}
+
+// startCapacity 开始按容量计划调整容量，在 New 应用全部配置之后调用。
+func (p *Pool) startCapacity() {
+	if len(p.capacity.rules) == 0 {
+		return
+	}
+	p.mu.Lock()
+	p.capacity.base = p.size
+	p.capacity.index = -1
+	p.applyCapacityLocked()
+	p.mu.Unlock()
+
+	go func() {
+		select {
+		case <-p.done:
+		case <-p.ctx.Done():
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.stopCapacityLocked()
+		p.capacity.rules = nil
+	}()
+}
+
+// stopCapacityLocked 停止容量计时器，调用方需持有 p.mu。
+func (p *Pool) stopCapacityLocked() {
+	c := &p.capacity
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.gen++
+}
+
+// applyCapacityLocked 匹配当前生效的规则，并在下一个时间边界再次匹配，调用方需持有 p.mu。
+func (p *Pool) applyCapacityLocked() {
+	c := &p.capacity
+	if len(c.rules) == 0 {
+		return
+	}
+	p.stopCapacityLocked()
+
+	now := time.Now().In(c.loc)
+	index := slices.IndexFunc(c.rules, func(r CapacityRule) bool { return r.active(now) })
+	if index != c.index {
+		c.index, c.rule, p.size = index, "", c.base
+		if index >= 0 {
+			c.rule, p.size = c.rules[index].Name, c.rules[index].Size
+		}
+		p.dispatchLocked()
+	}
+
+	next := nextBoundary(now, c.rules)
+	gen := c.gen
+	c.timer = time.AfterFunc(next.Sub(now), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if gen == p.capacity.gen {
+			p.applyCapacityLocked()
+		}
+	})
+}
+
+// nextBoundary 返回 now 之后最近的一个规则开始或结束时间。
+func nextBoundary(now time.Time, rules []CapacityRule) time.Time {
+	var next time.Time
+	for _, r := range rules {
+		for _, d := range [...]time.Duration{r.From, r.To} {
+			t := atTimeOfDay(now, d)
+			if !t.After(now) {
+				t = atTimeOfDay(now.AddDate(0, 0, 1), d)
+			}
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+// sinceMidnight 返回 t 距当天零点的时长。
+func sinceMidnight(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// atTimeOfDay 返回 t 当天距零点 d 的时刻，按墙上时间计算以适应夏令时切换。
+func atTimeOfDay(t time.Time, d time.Duration) time.Time {
+	y, m, day := t.Date()
+	return time.Date(y, m, day, int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second), int(d%time.Second), t.Location())
+}
@@ -0,0 +1,78 @@
+package bee
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCapacityRuleActive(t *testing.T) {
+	night := CapacityRule{From: 22 * time.Hour, To: 6 * time.Hour, Days: []time.Weekday{time.Friday}}
+	// 2026-10-16 是星期五。
+	at := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(16, 23), true},
+		{at(17, 3), true},
+		{at(17, 6), false},
+		{at(16, 3), false},
+		{at(17, 23), false},
+		{at(16, 12), false},
+	} {
+		if got := night.active(tc.now); got != tc.want {
+			t.Errorf("active(%v) = %v, want %v", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestNextBoundary(t *testing.T) {
+	rules := []CapacityRule{{From: 9 * time.Hour, To: 18 * time.Hour}, {From: 22 * time.Hour, To: 6 * time.Hour}}
+	for _, tc := range []struct{ now, want time.Time }{
+		{time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC), time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)},
+	} {
+		if got := nextBoundary(tc.now, rules); !got.Equal(tc.want) {
+			t.Errorf("nextBoundary(%v) = %v, want %v", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestCapacityScheduleStopsWhileIdle(t *testing.T) {
+	p := New(context.Background(), 8,
+		WithCapacitySchedule(time.UTC, CapacityRule{Name: "all-day", Size: 3}),
+		WithIdleTimeout(20*time.Millisecond, nil),
+	)
+	defer p.Exit()
+	if n, rule := p.Capacity(), p.Stats().Rule; n != 3 || rule != "all-day" {
+		t.Fatalf("capacity = %d under rule %q, want 3 under all-day", n, rule)
+	}
+	timer := func() *time.Timer {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.capacity.timer
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.State() != StateStopped {
+		if time.Now().After(deadline) {
+			t.Fatal("pool did not stop while idle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if timer() != nil {
+		t.Fatal("capacity timer still armed while the pool is stopped")
+	}
+
+	if err := p.Submit(func(context.Context, int64) {}); err != nil {
+		t.Fatalf("Submit() = %v", err)
+	}
+	if timer() == nil {
+		t.Fatal("capacity timer not re-armed after waking up")
+	}
+	if n := p.Capacity(); n != 3 {
+		t.Fatalf("capacity after waking up = %d, want 3", n)
+	}
+}
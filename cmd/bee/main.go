@@ -0,0 +1,172 @@
+// bee 是线程池的命令行工具。
+//
+// 用法:
+//
+//	bee ctl [-socket path] list
+//	bee ctl [-socket path] stats [pool]
+//	bee ctl [-socket path] pause <pool>
+//	bee ctl [-socket path] resume <pool>
+//	bee ctl [-socket path] resize <pool> <size>
+//	bee ctl [-socket path] drain <pool> [timeout]
+//	bee ctl [-socket path] dump-tasks <pool>
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cnk3x/bee"
+	"github.com/cnk3x/bee/admin"
+)
+
+const usage = `usage:
+  bee ctl [-socket path] list
+  bee ctl [-socket path] stats [pool]
+  bee ctl [-socket path] pause <pool>
+  bee ctl [-socket path] resume <pool>
+  bee ctl [-socket path] resize <pool> <size>
+  bee ctl [-socket path] drain <pool> [timeout]
+  bee ctl [-socket path] dump-tasks <pool>
+`
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "ctl" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := ctl(os.Args[2:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "bee:", err)
+		os.Exit(1)
+	}
+}
+
+// ctl 执行 ctl 子命令。
+func ctl(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage) }
+	socket := fs.String("socket", os.Getenv("BEE_ADMIN_SOCKET"), "admin socket path (default $BEE_ADMIN_SOCKET)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *socket == "" {
+		return fmt.Errorf("admin socket not set, use -socket or $BEE_ADMIN_SOCKET")
+	}
+
+	req, err := parseRequest(fs.Args())
+	if err != nil {
+		return err
+	}
+	c, err := admin.Dial(*socket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	switch req.Cmd {
+	case admin.CmdList:
+		var names []string
+		if err := c.Call(req, &names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Fprintln(out, name)
+		}
+	case admin.CmdDumpTasks:
+		var tasks []bee.TaskStatus
+		if err := c.Call(req, &tasks); err != nil {
+			return err
+		}
+		printTasks(out, tasks)
+	case admin.CmdStats:
+		var stats []bee.Stats
+		if err := c.Call(req, &stats); err != nil {
+			return err
+		}
+		printStats(out, stats...)
+	default:
+		var stats bee.Stats
+		if err := c.Call(req, &stats); err != nil {
+			return err
+		}
+		printStats(out, stats)
+	}
+	return nil
+}
+
+// parseRequest 将命令行参数解析为请求。
+func parseRequest(args []string) (admin.Request, error) {
+	if len(args) == 0 {
+		return admin.Request{}, fmt.Errorf("missing command\n%s", usage)
+	}
+	req := admin.Request{Cmd: args[0]}
+	args = args[1:]
+
+	switch req.Cmd {
+	case admin.CmdList:
+		return req, nil
+	case admin.CmdStats:
+		if len(args) > 0 {
+			req.Pool = args[0]
+		}
+		return req, nil
+	case admin.CmdPause, admin.CmdResume, admin.CmdResize, admin.CmdDrain, admin.CmdDumpTasks:
+	default:
+		return req, fmt.Errorf("unknown command %q\n%s", req.Cmd, usage)
+	}
+
+	if len(args) == 0 {
+		return req, fmt.Errorf("%s: missing pool name", req.Cmd)
+	}
+	req.Pool = args[0]
+	switch req.Cmd {
+	case admin.CmdResize:
+		if len(args) < 2 {
+			return req, fmt.Errorf("resize: missing size")
+		}
+		size, err := strconv.Atoi(args[1])
+		if err != nil {
+			return req, fmt.Errorf("resize: %w", err)
+		}
+		req.Size = size
+	case admin.CmdDrain:
+		if len(args) > 1 {
+			d, err := time.ParseDuration(args[1])
+			if err != nil {
+				return req, fmt.Errorf("drain: %w", err)
+			}
+			req.Timeout = d
+		}
+	}
+	return req, nil
+}
+
+// printStats 以表格形式输出线程池状态。
+func printStats(out io.Writer, stats ...bee.Stats) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPAUSED\tCAPACITY\tRULE\tRUNNING\tWAITING\tWORKED")
+	for _, s := range stats {
+		rule := cmp.Or(s.Rule, "-")
+		fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\t%d\t%d\t%d\n", s.Name, s.State, s.Paused, s.Capacity, rule, s.Running, s.Waiting, s.Worked)
+	}
+	w.Flush()
+}
+
+// printTasks 以表格形式输出任务。
+func printTasks(out io.Writer, tasks []bee.TaskStatus) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tNAME\tTENANT\tPRIORITY\tRUNNING\tSUBMITTED\tSTARTED")
+	for _, t := range tasks {
+		started := "-"
+		if !t.Started.IsZero() {
+			started = t.Started.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%t\t%s\t%s\n", t.Index, t.Name, t.Tenant, t.Priority, t.Running, t.Submitted.Format(time.RFC3339), started)
+	}
+	w.Flush()
+}
@@ -0,0 +1,157 @@
+package bee
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"time"
+)
+
+// WithName 设置线程池名称，用于管理接口、统计和审计。
+//
+// 参数:
+//
+//	name string: 线程池名称。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithName(name string) Option {
+	return func(p *Pool) { p.name = name }
+}
+
+// Name 返回线程池名称。
+//
+// 返回值:
+//
+//	string: 线程池名称。
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Pause 暂停分配槽位，正在运行的任务不受影响，新提交的任务进入等待队列。
+func (p *Pool) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+}
+
+// Resume 恢复分配槽位。
+func (p *Pool) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = false
+	p.dispatchLocked()
+}
+
+// Resize 调整可以同时运行的任务数量。
+//
+// 缩小容量时不会中断正在运行的任务，而是等它们结束后不再补充槽位。
+//
+// 参数:
+//
+//	size int: 新的容量。
+func (p *Pool) Resize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = size
+	p.dispatchLocked()
+}
+
+// Capacity 返回可以同时运行的任务数量。
+//
+// 返回值:
+//
+//	int: 线程池容量。
+func (p *Pool) Capacity() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size
+}
+
+// Stats 是线程池的状态快照。
+type Stats struct {
+	Name     string `json:"name"`               // 线程池名称
+	State    State  `json:"state"`              // 运行状态
+	Paused   bool   `json:"paused"`             // 是否暂停分配槽位
+	Capacity int    `json:"capacity"`           // 可以同时运行的任务数量
+	Rule     string `json:"rule,omitempty"`     // 当前生效的容量规则
+	Throttle int    `json:"throttle,omitempty"` // 压力限流后的有效容量，0 表示未限流
+	Running  int    `json:"running"`            // 正在运行的任务数量
+	Waiting  int    `json:"waiting"`            // 等待槽位的任务数量
+	Worked   int64  `json:"worked"`             // 已完成的任务数量
+	Rejected int64  `json:"rejected"`           // 未能提交的任务数量
+	Panics   int64  `json:"panics"`             // 发生 panic 的任务数量
+}
+
+// Stats 返回线程池的状态快照。
+//
+// 返回值:
+//
+//	Stats: 状态快照。
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Stats{
+		Name:     p.name,
+		State:    p.state,
+		Paused:   p.paused,
+		Capacity: p.size,
+		Rule:     p.capacity.rule,
+		Throttle: p.pressure.limit,
+		Running:  int(p.running.Load()),
+		Waiting:  len(p.queued),
+		Worked:   p.worked.Load(),
+		Rejected: p.rejected.Load(),
+		Panics:   p.panics.Load(),
+	}
+}
+
+// TaskStatus 是一个运行中或排队中任务的快照。
+type TaskStatus struct {
+	Index     int64     `json:"index,omitempty"`  // 任务索引，排队中的任务为 0
+	Name      string    `json:"name,omitempty"`   // 任务名称
+	Tenant    string    `json:"tenant,omitempty"` // 所属租户
+	Priority  int       `json:"priority"`         // 任务优先级
+	Running   bool      `json:"running"`          // 是否持有槽位
+	Submitted time.Time `json:"submitted"`        // 提交时间
+	Started   time.Time `json:"started,omitzero"` // 开始运行的时间
+}
+
+// Tasks 返回运行中和排队中的任务快照，运行中的任务在前，排队中的任务按入队顺序排列。
+//
+// 返回值:
+//
+//	[]TaskStatus: 任务快照。
+func (p *Pool) Tasks() []TaskStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]TaskStatus, 0, len(p.active)+len(p.queued))
+	for t := range p.active {
+		if _, ok := p.queued[t]; !ok {
+			out = append(out, t.status())
+		}
+	}
+	slices.SortFunc(out, func(a, b TaskStatus) int { return cmp.Compare(a.Index, b.Index) })
+
+	queued := slices.Collect(maps.Keys(p.queued))
+	slices.SortFunc(queued, func(a, b *task) int { return cmp.Compare(a.ticket.Seq, b.ticket.Seq) })
+	for _, t := range queued {
+		out = append(out, t.status())
+	}
+	return out
+}
+
+// status 返回任务快照，调用方需持有 pool.mu。
+func (t *task) status() TaskStatus {
+	return TaskStatus{
+		Index:     t.index,
+		Name:      t.name,
+		Tenant:    t.tenant,
+		Priority:  t.priority,
+		Running:   t.holding,
+		Submitted: t.submitted,
+		Started:   t.startedAt,
+	}
+}
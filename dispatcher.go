@@ -0,0 +1,168 @@
+package bee
+
+import "context"
+
+// Dispatcher 是只处理一种输入类型的线程池，每个输入交给同一个处理函数。
+type Dispatcher[In, Out any] struct {
+	pool    *Pool
+	handler func(ctx context.Context, in In) (Out, error)
+}
+
+// NewDispatcher 创建一个处理 In 类型输入、产出 Out 类型结果的线程池。
+//
+// 参数:
+//
+//	ctx context.Context: 上下文，用于控制工作线程的生命周期。
+//	size int: 可以同时处理的输入数量。
+//	handler func(ctx context.Context, in In) (Out, error): 处理函数。
+//	opts ...Option: 可选的线程池配置。
+//
+// 返回值:
+//
+//	*Dispatcher[In, Out]: 新创建的 Dispatcher。
+func NewDispatcher[In, Out any](ctx context.Context, size int, handler func(ctx context.Context, in In) (Out, error), opts ...Option) *Dispatcher[In, Out] {
+	return &Dispatcher[In, Out]{pool: New(ctx, size, opts...), handler: handler}
+}
+
+// Pool 返回 Dispatcher 使用的线程池，可用于注册到管理接口或查看统计信息。
+//
+// 返回值:
+//
+//	*Pool: 线程池。
+func (d *Dispatcher[In, Out]) Pool() *Pool {
+	return d.pool
+}
+
+// Submit 提交一个输入，阻塞到获得槽位，返回其结果的 Future。
+//
+// 参数:
+//
+//	in In: 输入。
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	*Future[Out]: 处理结果，提交失败时 Future 立即完成并带有提交的错误。
+func (d *Dispatcher[In, Out]) Submit(in In, opts ...TaskOption) *Future[Out] {
+	return d.SubmitContext(context.Background(), in, opts...)
+}
+
+// SubmitContext 与 Submit 相同，但 ctx 取消时停止等待槽位。
+//
+// 参数:
+//
+//	ctx context.Context: 控制等待过程的上下文。
+//	in In: 输入。
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	*Future[Out]: 处理结果，提交失败时 Future 立即完成并带有提交的错误。
+func (d *Dispatcher[In, Out]) SubmitContext(ctx context.Context, in In, opts ...TaskOption) *Future[Out] {
+	j := &dispatch[In, Out]{Future: Future[Out]{done: make(chan struct{})}, d: d, in: in}
+	if err := d.pool.SubmitContext(ctx, j.run, append(opts[:len(opts):len(opts)], onDrop(j.drop))...); err != nil {
+		j.drop(err)
+	}
+	return &j.Future
+}
+
+// SubmitBatch 按顺序提交一批输入。
+//
+// 参数:
+//
+//	ins []In: 输入。
+//	opts ...TaskOption: 每个输入使用的任务选项。
+//
+// 返回值:
+//
+//	[]*Future[Out]: 与输入一一对应的结果。
+func (d *Dispatcher[In, Out]) SubmitBatch(ins []In, opts ...TaskOption) []*Future[Out] {
+	fs := make([]*Future[Out], len(ins))
+	for i, in := range ins {
+		fs[i] = d.Submit(in, opts...)
+	}
+	return fs
+}
+
+// Close 停止接收新的输入，并等待已开始处理的输入全部完成。
+//
+// 参数:
+//
+//	ctx context.Context: 等待的期限。
+//
+// 返回值:
+//
+//	error: ctx 先于处理完成时返回 ctx 的错误。
+func (d *Dispatcher[In, Out]) Close(ctx context.Context) error {
+	return d.pool.Shutdown(ctx)
+}
+
+// Future 是 Dispatcher 中一个输入的处理结果。
+type Future[Out any] struct {
+	done chan struct{}
+	out  Out
+	err  error
+}
+
+// complete 记录结果并唤醒等待者。
+func (f *Future[Out]) complete(out Out, err error) {
+	f.out, f.err = out, err
+	close(f.done)
+}
+
+// dispatch 是提交到线程池的一个输入，与其结果一起分配。
+type dispatch[In, Out any] struct {
+	Future[Out]
+	d  *Dispatcher[In, Out]
+	in In
+}
+
+// run 在线程池中处理输入。
+func (j *dispatch[In, Out]) run(ctx context.Context, _ int64) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero Out
+			j.complete(zero, &PanicError{Value: r})
+			// 继续 panic，由线程池统计和通知观察者。
+			panic(r)
+		}
+	}()
+	out, err := j.d.handler(ctx, j.in)
+	Fail(ctx, err)
+	j.complete(out, err)
+}
+
+// drop 在处理函数不会被调用时以 err 完成 Future。
+func (j *dispatch[In, Out]) drop(err error) {
+	var zero Out
+	j.complete(zero, err)
+}
+
+// Done 返回结果就绪时关闭的通道。
+//
+// 返回值:
+//
+//	<-chan struct{}: 结果就绪时关闭。
+func (f *Future[Out]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait 等待并返回处理结果。
+//
+// 参数:
+//
+//	ctx context.Context: 等待的期限。
+//
+// 返回值:
+//
+//	Out: 处理函数的返回值。
+//	error: 处理函数的错误、panic 或提交失败的错误，ctx 先于结果就绪时返回 ctx 的错误。
+func (f *Future[Out]) Wait(ctx context.Context) (Out, error) {
+	select {
+	case <-f.done:
+		return f.out, f.err
+	case <-ctx.Done():
+		var zero Out
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,73 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestDispatcherFutures(t *testing.T) {
+	errOdd := errors.New("odd")
+	d := bee.NewDispatcher(context.Background(), 3, func(_ context.Context, in int) (string, error) {
+		switch {
+		case in == 7:
+			panic("seven")
+		case in%2 == 1:
+			return "", errOdd
+		}
+		return strconv.Itoa(in), nil
+	})
+	fs := d.SubmitBatch([]int{0, 1, 2, 3, 4, 5, 6, 7, 8})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for in, f := range fs {
+		out, err := f.Wait(ctx)
+		var pe *bee.PanicError
+		switch {
+		case in == 7:
+			if !errors.As(err, &pe) {
+				t.Fatalf("Wait() for %d = %v, want a PanicError", in, err)
+			}
+		case in%2 == 1:
+			if !errors.Is(err, errOdd) {
+				t.Fatalf("Wait() for %d = %v, want errOdd", in, err)
+			}
+		case err != nil || out != strconv.Itoa(in):
+			t.Fatalf("Wait() for %d = %q, %v", in, out, err)
+		}
+	}
+
+	if err := d.Close(ctx); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	f := d.Submit(10)
+	select {
+	case <-f.Done():
+	case <-ctx.Done():
+		t.Fatal("Future of an input submitted after Close is not done")
+	}
+	if _, err := f.Wait(ctx); err == nil {
+		t.Fatal("Wait() for an input submitted after Close = nil")
+	}
+}
+
+func TestDispatcherWaitHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	d := bee.NewDispatcher(context.Background(), 1, func(context.Context, int) (int, error) {
+		<-release
+		return 0, nil
+	})
+	defer d.Pool().Exit()
+	f := d.Submit(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,231 @@
+package bee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked 表示锁文件正被其他存活的进程持有。
+var ErrLocked = errors.New("bee: lock held by another process")
+
+// LockError 描述了锁文件当前的持有者，errors.Is(err, ErrLocked) 成立。
+type LockError struct {
+	PID       int       // 持有者记录的进程号
+	Host      string    // 持有者记录的主机名
+	Heartbeat time.Time // 最近一次心跳的时间
+	// Stale 表示心跳已过期且记录的进程已不存在。
+	// 锁仍被持有说明描述符被子进程继承或持有者位于其他 PID 命名空间，锁不会被清理。
+	Stale bool
+}
+
+func (e *LockError) Error() string {
+	msg := fmt.Sprintf("bee: lock held by pid %d on %s, last heartbeat %s", e.PID, e.Host, e.Heartbeat.Format(time.RFC3339))
+	if e.Stale {
+		msg += " (stale)"
+	}
+	return msg
+}
+
+func (e *LockError) Unwrap() error { return ErrLocked }
+
+// PanicError 包装了任务运行时发生的 panic。
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("bee: task panic: %v", e.Value) }
+
+// ExclusiveOption 定义了 RunExclusive 的可选配置。
+type ExclusiveOption func(*lease)
+
+// HeartbeatInterval 设置持锁期间刷新心跳的间隔，默认为 5 秒。
+//
+// 参数:
+//
+//	d time.Duration: 心跳间隔。
+//
+// 返回值:
+//
+//	ExclusiveOption: RunExclusive 配置。
+func HeartbeatInterval(d time.Duration) ExclusiveOption {
+	return func(l *lease) { l.heartbeat = d }
+}
+
+// StaleAfter 设置心跳超过多久未刷新时视为过期锁，默认为 30 秒。
+//
+// 过期只用于在 LockError 中报告，不会清理锁：只要 flock 仍被持有，就有存活的描述符引用锁文件。
+//
+// 参数:
+//
+//	d time.Duration: 过期时间。
+//
+// 返回值:
+//
+//	ExclusiveOption: RunExclusive 配置。
+func StaleAfter(d time.Duration) ExclusiveOption {
+	return func(l *lease) { l.staleAfter = d }
+}
+
+// RunExclusive 在获得基于 flock 的文件锁后，将任务提交到线程池执行，并等待任务结束。
+//
+// 同一台主机上使用相同 lockPath 的多个进程中，同一时间只有一个能执行任务。
+// 持锁期间会定期向锁文件写入心跳；进程崩溃时内核会自动释放锁。
+// 任务结束或 panic 后释放锁并删除锁文件。
+//
+// 参数:
+//
+//	ctx context.Context: 上下文，取消时任务收到的上下文也会被取消。
+//	p *Pool: 执行任务的线程池。
+//	lockPath string: 锁文件路径。
+//	f func(ctx context.Context) error: 要执行的任务函数。
+//	opts ...ExclusiveOption: 可选配置。
+//
+// 返回值:
+//
+//	error: 锁被占用时返回 ErrLocked 或描述持有者的 *LockError，否则返回提交或执行任务的错误。
+func RunExclusive(ctx context.Context, p *Pool, lockPath string, f func(ctx context.Context) error, opts ...ExclusiveOption) error {
+	l := &lease{path: lockPath, heartbeat: 5 * time.Second, staleAfter: 30 * time.Second}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go l.keepAlive(stop)
+
+	done := make(chan error, 1)
+	err := p.SubmitContext(ctx, func(tctx context.Context, _ int64) {
+		tctx, cancel := context.WithCancel(tctx)
+		defer cancel()
+		defer context.AfterFunc(ctx, cancel)()
+		done <- safeCall(tctx, f)
+	})
+	if err != nil {
+		return err
+	}
+	return <-done
+}
+
+// safeCall 执行任务函数并将 panic 转换为 PanicError。
+func safeCall(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r}
+		}
+	}()
+	return f(ctx)
+}
+
+// lease 是基于 flock 的文件锁租约。
+type lease struct {
+	path       string
+	heartbeat  time.Duration
+	staleAfter time.Duration
+	file       *os.File
+}
+
+// acquire 获取锁，加锁后发现锁文件已被替换时会重试。
+func (l *lease) acquire() error {
+	for range 3 {
+		f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return err
+		}
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if !locked {
+			err := l.holder(f)
+			f.Close()
+			return err
+		}
+		// 加锁期间锁文件可能已被其他进程删除或替换，此时需要重新加锁。
+		if !samePath(l.path, f) {
+			f.Close()
+			continue
+		}
+		l.file = f
+		return l.beat()
+	}
+	return ErrLocked
+}
+
+// holder 读取锁文件中记录的持有者，记录无法解析时返回 ErrLocked。
+func (l *lease) holder(f *os.File) error {
+	data := make([]byte, 256)
+	n, _ := f.ReadAt(data, 0)
+	fields := strings.Fields(string(data[:n]))
+	if len(fields) != 3 {
+		return ErrLocked
+	}
+	pid, err1 := strconv.Atoi(fields[0])
+	beat, err2 := strconv.ParseInt(fields[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		return ErrLocked
+	}
+	e := &LockError{PID: pid, Host: fields[1], Heartbeat: time.Unix(0, beat)}
+	if time.Since(e.Heartbeat) >= l.staleAfter {
+		host, _ := os.Hostname()
+		e.Stale = e.Host == host && !processAlive(pid)
+	}
+	return e
+}
+
+// beat 将持有者信息和当前时间写入锁文件。
+func (l *lease) beat() error {
+	host, _ := os.Hostname()
+	record := fmt.Sprintf("%d %s %d\n", os.Getpid(), host, time.Now().UnixNano())
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := l.file.WriteAt([]byte(record), 0)
+	return err
+}
+
+// keepAlive 定期刷新心跳，直到 stop 关闭。
+func (l *lease) keepAlive(stop <-chan struct{}) {
+	ticker := time.NewTicker(l.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.beat()
+		}
+	}
+}
+
+// release 删除锁文件并释放锁。
+func (l *lease) release() {
+	removeSame(l.path, l.file)
+	l.file.Close()
+}
+
+// samePath 判断路径当前指向的是否仍是已打开的文件。
+func samePath(path string, f *os.File) bool {
+	a, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	b, err := f.Stat()
+	return err == nil && os.SameFile(a, b)
+}
+
+// removeSame 在路径仍指向已打开的文件时删除它。
+func removeSame(path string, f *os.File) {
+	if samePath(path, f) {
+		os.Remove(path)
+	}
+}
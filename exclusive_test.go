@@ -0,0 +1,89 @@
+//go:build unix
+
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestRunExclusiveReportsHolder(t *testing.T) {
+	p := bee.New(context.Background(), 2)
+	path := filepath.Join(t.TempDir(), "lock")
+	inside := make(chan struct{})
+	release := make(chan struct{})
+	go bee.RunExclusive(context.Background(), p, path, func(context.Context) error {
+		close(inside)
+		<-release
+		return nil
+	})
+	<-inside
+	defer close(release)
+
+	err := bee.RunExclusive(context.Background(), p, path, func(context.Context) error { return nil })
+	var le *bee.LockError
+	if !errors.Is(err, bee.ErrLocked) || !errors.As(err, &le) {
+		t.Fatalf("RunExclusive() = %v, want *LockError", err)
+	}
+	if le.PID != os.Getpid() || le.Stale {
+		t.Fatalf("LockError = %+v, want pid %d and not stale", le, os.Getpid())
+	}
+}
+
+func TestRunExclusiveKeepsStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		t.Fatal(err)
+	}
+	host, _ := os.Hostname()
+	// 描述符仍然持有锁，但记录的进程已不存在，例如描述符被子进程继承。
+	fmt.Fprintf(f, "%d %s %d\n", 1<<22+12345, host, time.Now().Add(-time.Hour).UnixNano())
+
+	p := bee.New(context.Background(), 1)
+	ran := false
+	err = bee.RunExclusive(context.Background(), p, path, func(context.Context) error {
+		ran = true
+		return nil
+	}, bee.StaleAfter(time.Minute))
+	var le *bee.LockError
+	if !errors.As(err, &le) || !le.Stale {
+		t.Fatalf("RunExclusive() = %v, want stale *LockError", err)
+	}
+	if ran {
+		t.Fatal("task ran while the lock was held")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("lock file removed: %v", err)
+	}
+}
+
+func TestRunExclusiveHonorsContextWhileWaiting(t *testing.T) {
+	p := bee.New(context.Background(), 1)
+	release := make(chan struct{})
+	defer close(release)
+	p.Submit(func(context.Context, int64) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "lock")
+	err := bee.RunExclusive(ctx, p, path, func(context.Context) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunExclusive() = %v, want context.DeadlineExceeded", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
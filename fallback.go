@@ -0,0 +1,134 @@
+package bee
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Executor 是可以提交任务的执行器，*Pool 和 *FallbackExecutor 都实现了该接口。
+type Executor interface {
+	SubmitContext(ctx context.Context, f func(ctx context.Context, index int64), opts ...TaskOption) error
+}
+
+// Fallback 创建一个按顺序尝试多个执行器的执行器。
+//
+// 任务先提交到 primary，被拒绝或在等待预算内没有获得槽位时，依次改为提交到 secondary 中的执行器。
+// 最后一个执行器不受等待预算限制。
+//
+// 参数:
+//
+//	primary Executor: 首选执行器。
+//	secondary ...Executor: 备用执行器，按顺序尝试。
+//
+// 返回值:
+//
+//	*FallbackExecutor: 溢出路由执行器。
+func Fallback(primary Executor, secondary ...Executor) *FallbackExecutor {
+	execs := append([]Executor{primary}, secondary...)
+	return &FallbackExecutor{execs: execs, routed: make([]atomic.Int64, len(execs))}
+}
+
+// FallbackExecutor 将任务路由到第一个能接收它的执行器，并记录每个执行器接收的任务数量。
+type FallbackExecutor struct {
+	execs  []Executor
+	budget time.Duration
+	routed []atomic.Int64
+}
+
+// WaitBudget 设置在每个非最后的执行器上等待槽位的最长时间，默认为 0，即只在有空闲槽位时提交。
+//
+// 参数:
+//
+//	d time.Duration: 等待预算。
+//
+// 返回值:
+//
+//	*FallbackExecutor: 执行器本身，便于链式调用。
+func (e *FallbackExecutor) WaitBudget(d time.Duration) *FallbackExecutor {
+	e.budget = d
+	return e
+}
+
+// Submit 提交一个任务，见 SubmitContext。
+//
+// 参数:
+//
+//	f func(ctx context.Context, index int64): 要执行的任务函数。
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	error: 所有执行器都未能接收任务时返回最后一个执行器的错误。
+func (e *FallbackExecutor) Submit(f func(ctx context.Context, index int64), opts ...TaskOption) error {
+	return e.SubmitContext(context.Background(), f, opts...)
+}
+
+// SubmitContext 依次尝试各个执行器，直到任务被接收。
+//
+// 任务运行时可以通过 FallbackLevel 获取它被路由到的执行器序号。
+// ctx 取消或任务因唯一键被拒绝时不再尝试后面的执行器。
+//
+// 参数:
+//
+//	ctx context.Context: 控制等待过程的上下文。
+//	f func(ctx context.Context, index int64): 要执行的任务函数。
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	error: 所有执行器都未能接收任务时返回最后一个执行器的错误。
+func (e *FallbackExecutor) SubmitContext(ctx context.Context, f func(ctx context.Context, index int64), opts ...TaskOption) error {
+	var err error
+	for i, exec := range e.execs {
+		level := i
+		run := func(ctx context.Context, index int64) {
+			f(context.WithValue(ctx, fallbackKey{}, level), index)
+		}
+		if i == len(e.execs)-1 {
+			err = exec.SubmitContext(ctx, run, opts...)
+		} else {
+			err = exec.SubmitContext(ctx, run, append(opts[:len(opts):len(opts)], MaxWait(e.budget))...)
+		}
+		if err == nil {
+			e.routed[i].Add(1)
+			return nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrDuplicate) {
+			return err
+		}
+	}
+	return err
+}
+
+// Routed 返回每个执行器接收的任务数量，顺序与创建时的执行器顺序一致。
+//
+// 返回值:
+//
+//	[]int64: 每个执行器接收的任务数量。
+func (e *FallbackExecutor) Routed() []int64 {
+	out := make([]int64, len(e.routed))
+	for i := range e.routed {
+		out[i] = e.routed[i].Load()
+	}
+	return out
+}
+
+// fallbackKey 是执行器序号在上下文中的键。
+type fallbackKey struct{}
+
+// FallbackLevel 返回任务被路由到的执行器序号，0 表示首选执行器。
+//
+// 参数:
+//
+//	ctx context.Context: 任务函数收到的上下文。
+//
+// 返回值:
+//
+//	int: 执行器序号。
+//	bool: 任务是否经由 FallbackExecutor 提交。
+func FallbackLevel(ctx context.Context) (int, bool) {
+	level, ok := ctx.Value(fallbackKey{}).(int)
+	return level, ok
+}
@@ -0,0 +1,129 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// occupy 占满线程池的全部 size 个槽位，返回释放它们的函数。
+func occupy(t *testing.T, p *bee.Pool, size int) func() {
+	t.Helper()
+	release := make(chan struct{})
+	for range size {
+		started := make(chan struct{})
+		if err := p.Submit(func(context.Context, int64) {
+			close(started)
+			<-release
+		}); err != nil {
+			t.Fatal(err)
+		}
+		<-started
+	}
+	return func() { close(release) }
+}
+
+// levelOf 通过 e 提交一个任务并返回它运行时的 FallbackLevel。
+func levelOf(t *testing.T, e *bee.FallbackExecutor, opts ...bee.TaskOption) int {
+	t.Helper()
+	levels := make(chan int, 1)
+	if err := e.Submit(func(ctx context.Context, _ int64) {
+		level, ok := bee.FallbackLevel(ctx)
+		if !ok {
+			level = -1
+		}
+		levels <- level
+	}, opts...); err != nil {
+		t.Fatalf("Submit() = %v", err)
+	}
+	return <-levels
+}
+
+func TestFallbackRoutes(t *testing.T) {
+	primary, secondary, last := bee.New(context.Background(), 1), bee.New(context.Background(), 1), bee.New(context.Background(), 1)
+	defer primary.Exit()
+	defer secondary.Exit()
+	defer last.Exit()
+	e := bee.Fallback(primary, secondary, last)
+
+	if level := levelOf(t, e); level != 0 {
+		t.Fatalf("level with an idle primary = %d, want 0", level)
+	}
+	releasePrimary := occupy(t, primary, 1)
+	if level := levelOf(t, e); level != 1 {
+		t.Fatalf("level with a busy primary = %d, want 1", level)
+	}
+	releaseSecondary := occupy(t, secondary, 1)
+	if level := levelOf(t, e); level != 2 {
+		t.Fatalf("level with busy primary and secondary = %d, want 2", level)
+	}
+	releasePrimary()
+	releaseSecondary()
+
+	// 被拒绝的执行器同样会被跳过。
+	secondary.Exit()
+	primary.Exit()
+	if level := levelOf(t, e); level != 2 {
+		t.Fatalf("level with exited pools = %d, want 2", level)
+	}
+	if got := e.Routed(); !slices.Equal(got, []int64{1, 1, 2}) {
+		t.Fatalf("Routed() = %v, want [1 1 2]", got)
+	}
+	if _, ok := bee.FallbackLevel(context.Background()); ok {
+		t.Fatal("FallbackLevel() outside a fallback task = true")
+	}
+}
+
+func TestFallbackWaitBudget(t *testing.T) {
+	primary, secondary := bee.New(context.Background(), 1), bee.New(context.Background(), 1)
+	defer primary.Exit()
+	defer secondary.Exit()
+	e := bee.Fallback(primary, secondary).WaitBudget(time.Second)
+
+	// 主执行器在预算内空出槽位时仍使用主执行器。
+	release := occupy(t, primary, 1)
+	time.AfterFunc(10*time.Millisecond, release)
+	if level := levelOf(t, e); level != 0 {
+		t.Fatalf("level when the primary frees up within the budget = %d, want 0", level)
+	}
+
+	e.WaitBudget(20 * time.Millisecond)
+	release = occupy(t, primary, 1)
+	defer release()
+	start := time.Now()
+	if level := levelOf(t, e); level != 1 {
+		t.Fatalf("level after the budget ran out = %d, want 1", level)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("routed after %v, before the 20ms budget ran out", elapsed)
+	}
+}
+
+func TestFallbackStops(t *testing.T) {
+	primary, secondary := bee.New(context.Background(), 1), bee.New(context.Background(), 1)
+	defer primary.Exit()
+	defer secondary.Exit()
+	e := bee.Fallback(primary, secondary)
+
+	release := make(chan struct{})
+	defer close(release)
+	if err := e.Submit(func(context.Context, int64) { <-release }, bee.Unique("job", bee.UniqueActive)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Submit(func(context.Context, int64) {}, bee.Unique("job", bee.UniqueActive)); !errors.Is(err, bee.ErrDuplicate) {
+		t.Fatalf("Submit() of a duplicate = %v, want ErrDuplicate", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.SubmitContext(ctx, func(context.Context, int64) {}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SubmitContext() with a canceled context = %v, want context.Canceled", err)
+	}
+	if got := e.Routed(); !slices.Equal(got, []int64{1, 0}) {
+		t.Fatalf("Routed() = %v, want [1 0]", got)
+	}
+}
@@ -0,0 +1,91 @@
+package bee
+
+import (
+	"context"
+	"time"
+)
+
+// fanout 是一个请求可以同时占用的槽位名额。
+type fanout struct {
+	sem    chan struct{}
+	parent *fanout // 外层请求的名额，同时受其限制
+}
+
+// fanoutKey 是请求级并发名额在上下文中的键。
+type fanoutKey struct{}
+
+// WithFanout 为请求附加并发上限。
+//
+// 使用返回的上下文调用 SubmitContext 提交的任务，在任何线程池中同时运行或等待槽位的数量不超过 n，
+// 超出的提交会阻塞到该请求的其他任务结束，从而避免一个大请求占满共享的线程池。
+// 嵌套使用时同时受内外两层上限的限制。
+//
+// 任务如果使用同一个上下文提交子任务，子任务同样占用名额，名额用尽时会互相等待，应避免这样使用。
+//
+// 参数:
+//
+//	ctx context.Context: 请求的上下文。
+//	n int: 请求可以同时占用的槽位数量。
+//
+// 返回值:
+//
+//	context.Context: 附加了并发上限的上下文。
+func WithFanout(ctx context.Context, n int) context.Context {
+	parent, _ := ctx.Value(fanoutKey{}).(*fanout)
+	return context.WithValue(ctx, fanoutKey{}, &fanout{sem: make(chan struct{}, max(n, 1)), parent: parent})
+}
+
+// acquireFanout 在提交上下文带有并发上限时为任务占用名额。
+func (p *Pool) acquireFanout(ctx context.Context, t *task) error {
+	head, _ := ctx.Value(fanoutKey{}).(*fanout)
+	for f := head; f != nil; f = f.parent {
+		if err := p.takeFanout(ctx, t, f); err != nil {
+			// 归还已经占用的内层名额。
+			for g := head; g != f; g = g.parent {
+				<-g.sem
+			}
+			return err
+		}
+	}
+	t.fanout = head
+	return nil
+}
+
+// takeFanout 占用一个名额，遵守任务的 MaxWait 限制。
+func (p *Pool) takeFanout(ctx context.Context, t *task, f *fanout) error {
+	select {
+	case f.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if t.maxWait == 0 {
+		return ErrBusy
+	}
+
+	var expired <-chan time.Time
+	if t.maxWait > 0 {
+		timer := time.NewTimer(t.maxWait)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case f.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrClosed
+	case <-p.ctx.Done():
+		p.closeDone()
+		return ErrClosed
+	case <-expired:
+		return ErrBusy
+	}
+}
+
+// release 释放任务占用的名额，f 为 nil 时不做任何处理。
+func (f *fanout) release() {
+	for ; f != nil; f = f.parent {
+		<-f.sem
+	}
+}
@@ -0,0 +1,77 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// fanoutPeak 在 ctx 下并发提交 tasks 个任务，返回同时运行的最大数量。
+func fanoutPeak(t *testing.T, p *bee.Pool, ctx context.Context, tasks int) int32 {
+	t.Helper()
+	var (
+		wg            sync.WaitGroup
+		running, peak atomic.Int32
+	)
+	for range tasks {
+		wg.Add(1)
+		go func() {
+			err := p.SubmitContext(ctx, func(context.Context, int64) {
+				defer wg.Done()
+				n := running.Add(1)
+				for {
+					if old := peak.Load(); n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+			})
+			if err != nil {
+				wg.Done()
+				t.Errorf("SubmitContext() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return peak.Load()
+}
+
+func TestFanoutLimitsRequest(t *testing.T) {
+	p := bee.New(context.Background(), 8)
+	defer p.Exit()
+	if n := fanoutPeak(t, p, bee.WithFanout(context.Background(), 2), 40); n > 2 {
+		t.Fatalf("%d tasks of a request limited to 2 ran at once", n)
+	}
+	// 嵌套时内层的上限更宽松，仍受外层限制。
+	nested := bee.WithFanout(bee.WithFanout(context.Background(), 2), 5)
+	if n := fanoutPeak(t, p, nested, 40); n > 2 {
+		t.Fatalf("%d tasks of a nested request ran at once, outer limit is 2", n)
+	}
+}
+
+func TestFanoutMaxWait(t *testing.T) {
+	p := bee.New(context.Background(), 4)
+	defer p.Exit()
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx := bee.WithFanout(context.Background(), 1)
+	if err := p.SubmitContext(ctx, func(context.Context, int64) { <-release }); err != nil {
+		t.Fatalf("SubmitContext() = %v", err)
+	}
+	if err := p.SubmitContext(ctx, func(context.Context, int64) {}, bee.MaxWait(0)); !errors.Is(err, bee.ErrBusy) {
+		t.Fatalf("SubmitContext() over the request limit = %v, want ErrBusy", err)
+	}
+	// 其他请求不受影响。
+	done := make(chan struct{})
+	if err := p.SubmitContext(context.Background(), func(context.Context, int64) { close(done) }, bee.MaxWait(0)); err != nil {
+		t.Fatalf("SubmitContext() from another request = %v", err)
+	}
+	<-done
+}
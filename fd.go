@@ -0,0 +1,108 @@
+package bee
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrFDBudget 表示任务声明的描述符数量超过了 RLIMIT_NOFILE 减去保留数量，永远无法启动。
+var ErrFDBudget = errors.New("bee: task needs more descriptors than the budget")
+
+// fdRetry 是描述符不足时重新检查的间隔，也是打开描述符数量的缓存时间。
+const fdRetry = 50 * time.Millisecond
+
+// fdBudget 是按文件描述符准入的配置和状态，由 pool.mu 保护。
+type fdBudget struct {
+	on      bool
+	reserve int         // 为任务之外的代码保留的描述符数量
+	limit   int         // RLIMIT_NOFILE 的软限制
+	open    int         // 进程当前打开的描述符数量
+	base    int         // 没有任务持有描述符时进程打开的描述符数量
+	checked time.Time   // 上次读取 limit 和 open 的时间
+	held    int         // 已开始运行的任务声明的描述符总数
+	timer   *time.Timer // 描述符不足时的重试计时器
+}
+
+// FDs 声明任务运行时需要打开的文件描述符数量，配合 WithFDBudget 使用。
+//
+// 参数:
+//
+//	n int: 描述符数量。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func FDs(n int) TaskOption {
+	return func(t *task) { t.fds = max(n, 0) }
+}
+
+// WithFDBudget 按文件描述符预算准入声明了 FDs 的任务。
+//
+// 预算为 RLIMIT_NOFILE 的软限制减去 reserve 和进程当前打开的描述符数量，
+// 再减去已开始运行的任务声明了但尚未打开的描述符。尚未打开的数量按没有任务运行时的打开数量估算：
+// 之后新增的打开描述符视为任务已经打开的部分。
+// 声明的数量超过软限制减去 reserve 的任务在提交时返回 ErrFDBudget。
+// 等待队列中的下一个任务描述符不足时，它之后的任务也一起等待，线程池定期重新检查。
+// 平台不支持读取描述符数量时不做限制。
+//
+// 参数:
+//
+//	reserve int: 为任务之外的代码保留的描述符数量。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithFDBudget(reserve int) Option {
+	return func(p *Pool) { p.fd = fdBudget{on: true, reserve: reserve} }
+}
+
+// checkFDs 在提交时拒绝永远无法满足描述符预算的任务。
+func (p *Pool) checkFDs(t *task) error {
+	if !p.fd.on || t.fds == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.refreshFDsLocked() && t.fds > p.fd.limit-p.fd.reserve {
+		return ErrFDBudget
+	}
+	return nil
+}
+
+// fdsOKLocked 判断描述符预算是否足够启动任务，调用方需持有 p.mu。
+func (p *Pool) fdsOKLocked(t *task) bool {
+	if !p.fd.on || t.fds == 0 || t.started || !p.refreshFDsLocked() {
+		return true
+	}
+	opened := max(p.fd.open-p.fd.base, 0)
+	pending := max(p.fd.held-opened, 0)
+	return t.fds <= p.fd.limit-p.fd.reserve-p.fd.open-pending
+}
+
+// refreshFDsLocked 在缓存过期时重新读取描述符用量，返回用量是否可用，调用方需持有 p.mu。
+func (p *Pool) refreshFDsLocked() bool {
+	if now := time.Now(); now.Sub(p.fd.checked) >= fdRetry {
+		limit, open, err := fdUsage()
+		if err != nil {
+			return false
+		}
+		p.fd.limit, p.fd.open, p.fd.checked = limit, open, now
+		if p.fd.held == 0 {
+			p.fd.base = open
+		}
+	}
+	return true
+}
+
+// retryFDsLocked 在描述符不足时安排重新分配槽位，调用方需持有 p.mu。
+func (p *Pool) retryFDsLocked() {
+	if p.fd.timer != nil {
+		return
+	}
+	p.fd.timer = time.AfterFunc(fdRetry, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.fd.timer = nil
+		p.dispatchLocked()
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package bee
+
+import "errors"
+
+// fdUsage 在不支持的平台上返回错误，不做描述符限制。
+func fdUsage() (limit, open int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,100 @@
+//go:build linux
+
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// openFDs 返回进程当前打开的描述符数量。
+func openFDs(t *testing.T) int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("no /proc/self/fd")
+	}
+	return len(entries) - 1
+}
+
+func TestFDBudgetRejectsImpossibleTask(t *testing.T) {
+	p := bee.New(context.Background(), 2, bee.WithFDBudget(16))
+	if err := p.Submit(func(context.Context, int64) {}, bee.FDs(1<<30)); !errors.Is(err, bee.ErrFDBudget) {
+		t.Fatalf("Submit(FDs(1<<30)) = %v, want ErrFDBudget", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ran := make(chan struct{})
+	if err := p.SubmitContext(ctx, func(context.Context, int64) { close(ran) }); err != nil {
+		t.Fatalf("SubmitContext() = %v", err)
+	}
+	<-ran
+}
+
+func TestFDBudgetCountsOpenedDescriptorsOnce(t *testing.T) {
+	var old syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &old); err != nil {
+		t.Fatal(err)
+	}
+	limit := old
+	limit.Cur = uint64(openFDs(t) + 40)
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		t.Skip(err)
+	}
+	defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &old)
+
+	// 预算约为 30 个描述符，每个任务打开 8 个：重复计数时只能同时运行 2 个。
+	p := bee.New(context.Background(), 8, bee.WithFDBudget(10))
+	var (
+		wg            sync.WaitGroup
+		running, peak atomic.Int32
+		failed        atomic.Bool
+	)
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Submit(func(context.Context, int64) {
+				n := running.Add(1)
+				for m := peak.Load(); n > m; m = peak.Load() {
+					if peak.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				var files []*os.File
+				for range 8 {
+					f, err := os.Open(os.DevNull)
+					if err != nil {
+						failed.Store(true)
+						break
+					}
+					files = append(files, f)
+				}
+				time.Sleep(300 * time.Millisecond)
+				for _, f := range files {
+					f.Close()
+				}
+				running.Add(-1)
+			}, bee.FDs(8))
+			if err != nil {
+				t.Errorf("Submit() = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	p.Shutdown(context.Background())
+	if failed.Load() {
+		t.Fatal("a task ran out of descriptors")
+	}
+	if n := peak.Load(); n < 3 {
+		t.Fatalf("at most %d tasks ran at once, want at least 3", n)
+	}
+}
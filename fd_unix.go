@@ -0,0 +1,24 @@
+//go:build unix
+
+package bee
+
+import (
+	"os"
+	"syscall"
+)
+
+// fdUsage 返回 RLIMIT_NOFILE 的软限制和进程当前打开的描述符数量。
+func fdUsage() (limit, open int, err error) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, 0, err
+	}
+	for _, dir := range [...]string{"/proc/self/fd", "/dev/fd"} {
+		entries, err := os.ReadDir(dir)
+		if err == nil {
+			// 不计入读取目录时打开的描述符。
+			return int(min(rl.Cur, 1<<31-1)), len(entries) - 1, nil
+		}
+	}
+	return 0, 0, os.ErrNotExist
+}
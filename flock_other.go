@@ -0,0 +1,18 @@
+//go:build !unix
+
+package bee
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock 在不支持 flock 的平台上返回错误。
+func tryLock(*os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// processAlive 在无法检测进程的平台上总是认为进程存在。
+func processAlive(int) bool {
+	return true
+}
@@ -0,0 +1,232 @@
+package bee
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// IdempotencyStore 保存已完成任务的结果，实现需要能被并发调用。
+type IdempotencyStore interface {
+	// Get 返回 key 对应的未过期结果。
+	Get(key string) (result []byte, ok bool, err error)
+	// Put 保存 key 对应的结果，ttl 之后过期。
+	Put(key string, result []byte, ttl time.Duration) error
+}
+
+// NewIdempotency 创建一个按幂等键执行任务的执行器。
+//
+// 参数:
+//
+//	p *Pool: 执行任务的线程池。
+//	store IdempotencyStore: 保存任务结果的存储。
+//
+// 返回值:
+//
+//	*Idempotency: 幂等执行器。
+func NewIdempotency(p *Pool, store IdempotencyStore) *Idempotency {
+	return &Idempotency{pool: p, store: store, calls: map[string]*idemCall{}}
+}
+
+// Idempotency 保证同一个幂等键在有效期内只执行一次。
+type Idempotency struct {
+	pool  *Pool
+	store IdempotencyStore
+
+	mu    sync.Mutex
+	calls map[string]*idemCall // 正在执行的幂等键
+}
+
+// idemCall 是正在执行的幂等任务。
+type idemCall struct {
+	done   chan struct{}
+	result []byte
+	err    error
+}
+
+// RunIdempotent 按幂等键执行任务。
+//
+// 存储中已有该键的未过期结果时直接返回该结果而不再执行；同一进程内同一个键的并发调用只执行一次，
+// 其余调用等待并共享结果。任务成功后结果保存 ttl 时长，失败的任务不会被记录，下次调用会重新执行。
+//
+// 参数:
+//
+//	ctx context.Context: 上下文，取消时任务收到的上下文也会被取消。
+//	key string: 幂等键。
+//	ttl time.Duration: 结果的有效期。
+//	f func(ctx context.Context) ([]byte, error): 要执行的任务函数，返回需要保存的结果。
+//
+// 返回值:
+//
+//	[]byte: 任务的结果。
+//	error: 读写存储、提交或执行任务的错误。
+func (i *Idempotency) RunIdempotent(ctx context.Context, key string, ttl time.Duration, f func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	i.mu.Lock()
+	if c, ok := i.calls[key]; ok {
+		i.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.result, c.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c := &idemCall{done: make(chan struct{})}
+	i.calls[key] = c
+	i.mu.Unlock()
+
+	c.result, c.err = i.run(ctx, key, ttl, f)
+	i.mu.Lock()
+	delete(i.calls, key)
+	i.mu.Unlock()
+	close(c.done)
+	return c.result, c.err
+}
+
+// run 查询存储，未命中时在线程池中执行任务并保存结果。
+func (i *Idempotency) run(ctx context.Context, key string, ttl time.Duration, f func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if result, ok, err := i.store.Get(key); err != nil || ok {
+		return result, err
+	}
+
+	var result []byte
+	done := make(chan error, 1)
+	err := i.pool.SubmitContext(ctx, func(tctx context.Context, _ int64) {
+		tctx, cancel := context.WithCancel(tctx)
+		defer cancel()
+		defer context.AfterFunc(ctx, cancel)()
+		err := safeCall(tctx, func(ctx context.Context) (err error) {
+			result, err = f(ctx)
+			return err
+		})
+		Fail(tctx, err)
+		done <- err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	return result, i.store.Put(key, result, ttl)
+}
+
+// NewMemoryIdempotencyStore 创建一个内存中的幂等结果存储，进程重启后结果丢失。
+//
+// 返回值:
+//
+//	*MemoryIdempotencyStore: 内存存储。
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]idemEntry{}}
+}
+
+// MemoryIdempotencyStore 是保存在内存中的幂等结果存储。
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idemEntry
+}
+
+// idemEntry 是带过期时间的结果。
+type idemEntry struct {
+	result  []byte
+	expires time.Time
+}
+
+// Get 返回 key 对应的未过期结果，顺便清理已过期的结果。
+func (s *MemoryIdempotencyStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if ok && time.Now().After(e.expires) {
+		delete(s.entries, key)
+		ok = false
+	}
+	return e.result, ok, nil
+}
+
+// Put 保存 key 对应的结果。
+func (s *MemoryIdempotencyStore) Put(key string, result []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = idemEntry{result: result, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// NewFileIdempotencyStore 创建一个本地文件幂等结果存储，结果在进程重启后仍然有效。
+//
+// 参数:
+//
+//	dir string: 保存结果文件的目录，不存在时自动创建。
+//
+// 返回值:
+//
+//	*FileIdempotencyStore: 文件存储。
+//	error: 创建目录失败时返回的错误。
+func NewFileIdempotencyStore(dir string) (*FileIdempotencyStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileIdempotencyStore{dir: dir}, nil
+}
+
+// FileIdempotencyStore 是保存在本地文件中的幂等结果存储，每个键对应一个文件。
+type FileIdempotencyStore struct {
+	dir string
+}
+
+// path 返回 key 对应的文件路径。
+func (s *FileIdempotencyStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get 返回 key 对应的未过期结果，过期的结果文件会被删除。
+func (s *FileIdempotencyStore) Get(key string) ([]byte, bool, error) {
+	path := s.path(key)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) < 8 {
+		return nil, false, nil
+	}
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	if time.Now().After(expires) {
+		os.Remove(path)
+		return nil, false, nil
+	}
+	return data[8:], true, nil
+}
+
+// Put 保存 key 对应的结果，先写临时文件再重命名，避免留下不完整的结果。
+func (s *FileIdempotencyStore) Put(key string, result []byte, ttl time.Duration) error {
+	data := make([]byte, 8+len(result))
+	binary.BigEndian.PutUint64(data, uint64(time.Now().Add(ttl).UnixNano()))
+	copy(data[8:], result)
+
+	f, err := os.CreateTemp(s.dir, ".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
@@ -0,0 +1,130 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// counting 返回每次调用都使计数加一并返回固定结果的任务函数。
+func counting(runs *atomic.Int32, result string) func(context.Context) ([]byte, error) {
+	return func(context.Context) ([]byte, error) {
+		runs.Add(1)
+		return []byte(result), nil
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) bee.IdempotencyStore{
+		"Memory": func(*testing.T) bee.IdempotencyStore { return bee.NewMemoryIdempotencyStore() },
+		"File": func(t *testing.T) bee.IdempotencyStore {
+			s, err := bee.NewFileIdempotencyStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := bee.New(context.Background(), 4)
+			defer p.Exit()
+			ctx := context.Background()
+
+			t.Run("Repeat", func(t *testing.T) {
+				idem := bee.NewIdempotency(p, newStore(t))
+				var runs atomic.Int32
+				for range 3 {
+					got, err := idem.RunIdempotent(ctx, "k", time.Hour, counting(&runs, "first"))
+					if err != nil || string(got) != "first" {
+						t.Fatalf("RunIdempotent() = %q, %v", got, err)
+					}
+				}
+				if n := runs.Load(); n != 1 {
+					t.Fatalf("task ran %d times within the TTL, want 1", n)
+				}
+			})
+
+			t.Run("Expire", func(t *testing.T) {
+				idem := bee.NewIdempotency(p, newStore(t))
+				var runs atomic.Int32
+				idem.RunIdempotent(ctx, "k", 20*time.Millisecond, counting(&runs, "old"))
+				time.Sleep(40 * time.Millisecond)
+				got, err := idem.RunIdempotent(ctx, "k", time.Hour, counting(&runs, "new"))
+				if err != nil || string(got) != "new" || runs.Load() != 2 {
+					t.Fatalf("RunIdempotent() after the TTL = %q, %v with %d runs, want a fresh run", got, err, runs.Load())
+				}
+			})
+
+			t.Run("Failure", func(t *testing.T) {
+				idem := bee.NewIdempotency(p, newStore(t))
+				errBoom := errors.New("boom")
+				if _, err := idem.RunIdempotent(ctx, "k", time.Hour, func(context.Context) ([]byte, error) { return nil, errBoom }); !errors.Is(err, errBoom) {
+					t.Fatalf("RunIdempotent() = %v, want errBoom", err)
+				}
+				var runs atomic.Int32
+				if got, err := idem.RunIdempotent(ctx, "k", time.Hour, counting(&runs, "retry")); err != nil || string(got) != "retry" {
+					t.Fatalf("RunIdempotent() after a failure = %q, %v", got, err)
+				}
+			})
+
+			t.Run("Concurrent", func(t *testing.T) {
+				idem := bee.NewIdempotency(p, newStore(t))
+				var (
+					runs atomic.Int32
+					wg   sync.WaitGroup
+				)
+				for range 20 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						got, err := idem.RunIdempotent(ctx, "k", time.Hour, func(context.Context) ([]byte, error) {
+							runs.Add(1)
+							time.Sleep(20 * time.Millisecond)
+							return []byte("shared"), nil
+						})
+						if err != nil || string(got) != "shared" {
+							t.Errorf("RunIdempotent() = %q, %v", got, err)
+						}
+					}()
+				}
+				wg.Wait()
+				if n := runs.Load(); n != 1 {
+					t.Fatalf("task ran %d times for concurrent calls, want 1", n)
+				}
+			})
+		})
+	}
+}
+
+func TestFileIdempotencyStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	p := bee.New(context.Background(), 1)
+	defer p.Exit()
+
+	store, err := bee.NewFileIdempotencyStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runs atomic.Int32
+	if _, err := bee.NewIdempotency(p, store).RunIdempotent(context.Background(), "order/42", time.Hour, counting(&runs, "paid")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新的存储实例模拟进程重启。
+	store, err = bee.NewFileIdempotencyStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := bee.NewIdempotency(p, store).RunIdempotent(context.Background(), "order/42", time.Hour, counting(&runs, "again"))
+	if err != nil || string(got) != "paid" {
+		t.Fatalf("RunIdempotent() after restart = %q, %v, want the stored result", got, err)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("task ran %d times across restarts, want 1", n)
+	}
+}
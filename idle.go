@@ -0,0 +1,108 @@
+package bee
+
+import "time"
+
+// State 表示线程池的运行状态。
+type State string
+
+const (
+	// StateRunning 线程池正在运行，可以立即执行任务。
+	StateRunning State = "running"
+	// StateStopped 线程池因空闲而停止并释放了资源，下次提交任务时自动恢复运行。
+	StateStopped State = "stopped"
+)
+
+// idleConfig 是空闲自动停止的配置和计时器状态，由 pool.mu 保护。
+type idleConfig struct {
+	timeout  time.Duration
+	onChange func(State)
+	timer    *time.Timer
+	gen      uint64 // 计时器代数，用于忽略已失效的计时器
+}
+
+// WithIdleTimeout 开启空闲自动停止。
+//
+// 线程池在没有运行中和排队中的任务持续 d 之后进入 StateStopped 并释放资源，
+// 下次提交任务时自动恢复到 StateRunning。每次状态切换都会调用 onChange。
+//
+// 参数:
+//
+//	d time.Duration: 空闲时长。
+//	onChange func(State): 状态切换回调，可以为 nil。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithIdleTimeout(d time.Duration, onChange func(State)) Option {
+	return func(p *Pool) { p.idle.timeout, p.idle.onChange = d, onChange }
+}
+
+// State 返回线程池当前的运行状态。
+//
+// 返回值:
+//
+//	State: 运行状态。
+func (p *Pool) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// armIdleLocked 在线程池空闲时启动空闲计时器，调用方需持有 p.mu。
+func (p *Pool) armIdleLocked() {
+	if p.idle.timeout <= 0 || p.state == StateStopped || p.idle.timer != nil {
+		return
+	}
+	if p.running.Load() > 0 || len(p.queued) > 0 {
+		return
+	}
+	p.idle.gen++
+	gen := p.idle.gen
+	p.idle.timer = time.AfterFunc(p.idle.timeout, func() { p.idleExpired(gen) })
+}
+
+// idleExpired 在空闲计时器到期后停止线程池。
+func (p *Pool) idleExpired(gen uint64) {
+	p.mu.Lock()
+	if gen != p.idle.gen || p.running.Load() > 0 || len(p.queued) > 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.idle.timer = nil
+	p.state = StateStopped
+	// 停止压力采样和容量计时，恢复运行时重新开始。
+	p.stopPressureLocked()
+	p.stopCapacityLocked()
+	// 释放等待队列和唯一键表占用的内存。
+	p.queued = nil
+	p.unique = nil
+	p.mu.Unlock()
+	p.notifyState(StateStopped)
+}
+
+// wakeLocked 在提交任务时取消空闲计时器，线程池已停止时恢复运行，
+// 返回需要通知的新状态，调用方需持有 p.mu。
+func (p *Pool) wakeLocked() State {
+	if p.idle.timeout <= 0 {
+		return ""
+	}
+	if p.idle.timer != nil {
+		p.idle.timer.Stop()
+		p.idle.timer = nil
+		p.idle.gen++
+	}
+	if p.state != StateStopped {
+		return ""
+	}
+	p.state = StateRunning
+	p.startPressureLocked()
+	p.applyCapacityLocked()
+	return StateRunning
+}
+
+// notifyState 调用状态切换回调，state 为空时不做处理。
+func (p *Pool) notifyState(state State) {
+	if state != "" && p.idle.onChange != nil {
+		p.idle.onChange(state)
+	}
+}
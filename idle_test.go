@@ -0,0 +1,72 @@
+package bee_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestIdleStopAndWake(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		states []bee.State
+	)
+	changes := func() []bee.State {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(states)
+	}
+	p := bee.New(context.Background(), 2, bee.WithIdleTimeout(20*time.Millisecond, func(s bee.State) {
+		mu.Lock()
+		states = append(states, s)
+		mu.Unlock()
+	}))
+	defer p.Exit()
+	waitState := func(want bee.State) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for p.State() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("State() = %s, want %s", p.State(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if s := p.State(); s != bee.StateRunning {
+		t.Fatalf("initial State() = %s, want running", s)
+	}
+	// 运行中的任务阻止空闲停止。
+	release := make(chan struct{})
+	if err := p.Submit(func(context.Context, int64) { <-release }); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if s := p.State(); s != bee.StateRunning {
+		t.Fatalf("State() with a running task = %s, want running", s)
+	}
+	close(release)
+
+	waitState(bee.StateStopped)
+	if got := changes(); !slices.Equal(got, []bee.State{bee.StateStopped}) {
+		t.Fatalf("callbacks after idle stop = %v, want [stopped]", got)
+	}
+
+	done := make(chan struct{})
+	if err := p.Submit(func(context.Context, int64) { close(done) }); err != nil {
+		t.Fatalf("Submit() to a stopped pool = %v", err)
+	}
+	<-done
+	if got := changes(); !slices.Equal(got, []bee.State{bee.StateStopped, bee.StateRunning}) {
+		t.Fatalf("callbacks after waking up = %v, want [stopped running]", got)
+	}
+
+	waitState(bee.StateStopped)
+	if got := changes(); !slices.Equal(got, []bee.State{bee.StateStopped, bee.StateRunning, bee.StateStopped}) {
+		t.Fatalf("callbacks after the second idle stop = %v, want [stopped running stopped]", got)
+	}
+}
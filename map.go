@@ -0,0 +1,280 @@
+package bee
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrCheckpointMismatch 表示检查点文件记录的任务数量与本次调用不一致。
+var ErrCheckpointMismatch = errors.New("bee: checkpoint does not match batch")
+
+// checkpointMagic 是检查点文件的文件头。
+var checkpointMagic = [8]byte{'b', 'e', 'e', 'm', 'a', 'p', '0', '1'}
+
+// MapOption 定义了 MapResumable 的可选配置。
+type MapOption func(*mapConfig)
+
+// mapConfig 是 MapResumable 的配置。
+type mapConfig struct {
+	every time.Duration
+	opts  []TaskOption
+}
+
+// CheckpointEvery 设置写入检查点的间隔，默认为 5 秒。
+//
+// 参数:
+//
+//	d time.Duration: 写入间隔。
+//
+// 返回值:
+//
+//	MapOption: 批处理配置。
+func CheckpointEvery(d time.Duration) MapOption {
+	return func(c *mapConfig) { c.every = d }
+}
+
+// MapTaskOptions 设置提交每个元素时使用的任务选项。
+//
+// 参数:
+//
+//	opts ...TaskOption: 任务选项。
+//
+// 返回值:
+//
+//	MapOption: 批处理配置。
+func MapTaskOptions(opts ...TaskOption) MapOption {
+	return func(c *mapConfig) { c.opts = append(c.opts, opts...) }
+}
+
+// MapResumable 在线程池中对 [0, n) 的每个索引调用 f，并定期把已完成的索引写入检查点文件。
+//
+// 检查点文件记录低水位（之前的索引全部完成）和之后的完成位图。
+// 使用同一个检查点文件重新调用时会跳过已完成的索引，全部完成后删除检查点文件。
+// f 返回错误或 panic 时停止提交新的索引，等待已提交的索引结束并写入检查点后返回该错误，
+// 失败的索引在下次调用时会重新执行。
+//
+// 任务继承 ctx，ctx 取消时停止提交并写入检查点。
+//
+// 参数:
+//
+//	ctx context.Context: 控制整个批处理的上下文。
+//	p *Pool: 运行任务的线程池。
+//	n int: 元素数量。
+//	checkpoint string: 检查点文件的路径。
+//	f func(ctx context.Context, i int) error: 处理第 i 个元素的函数。
+//	opts ...MapOption: 可选配置。
+//
+// 返回值:
+//
+//	error: 第一个失败的错误，检查点文件读写失败或不匹配时返回相应的错误，ctx 取消时返回 ctx 的错误。
+func MapResumable(ctx context.Context, p *Pool, n int, checkpoint string, f func(ctx context.Context, i int) error, opts ...MapOption) error {
+	cfg := mapConfig{every: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	done, err := loadCheckpoint(checkpoint, n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		cancel()
+	}
+
+	// 定期写入检查点。
+	stop := make(chan struct{})
+	saved := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(cfg.every)
+		defer ticker.Stop()
+		var err error
+		for {
+			select {
+			case <-ticker.C:
+				if e := done.save(checkpoint); e != nil && err == nil {
+					err = e
+				}
+			case <-stop:
+				saved <- err
+				return
+			}
+		}
+	}()
+
+	// 任务函数不会被调用时同样视为失败，该索引在下次调用时重新执行。
+	taskOpts := append(cfg.opts[:len(cfg.opts):len(cfg.opts)], Inherit(), onDrop(func(err error) {
+		defer wg.Done()
+		fail(err)
+	}))
+	for i := range n {
+		if done.has(i) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		err := p.SubmitContext(ctx, func(tctx context.Context, _ int64) {
+			defer wg.Done()
+			if err := safeCall(tctx, func(tctx context.Context) error { return f(tctx, i) }); err != nil {
+				Fail(tctx, err)
+				fail(err)
+				return
+			}
+			done.set(i)
+		}, taskOpts...)
+		if err != nil {
+			wg.Done()
+			fail(err)
+			break
+		}
+	}
+	wg.Wait()
+	close(stop)
+	saveErr := <-saved
+
+	if firstErr == nil && done.complete() {
+		if err := os.Remove(checkpoint); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := done.save(checkpoint); err != nil {
+		saveErr = err
+	}
+	return errors.Join(firstErr, saveErr)
+}
+
+// doneSet 记录批处理中已完成的索引。
+type doneSet struct {
+	mu    sync.Mutex
+	n     int
+	words []uint64 // 完成位图，每个索引一位
+	low   int      // 低水位所在的字，之前的字已全部完成
+}
+
+// has 判断索引 i 是否已完成。
+func (s *doneSet) has(i int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.words[i/64]&(1<<(i%64)) != 0
+}
+
+// set 标记索引 i 已完成。
+func (s *doneSet) set(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.words[i/64] |= 1 << (i % 64)
+}
+
+// full 返回第 w 个字全部完成时的值。
+func (s *doneSet) full(w int) uint64 {
+	if rest := s.n - w*64; rest < 64 {
+		return 1<<rest - 1
+	}
+	return ^uint64(0)
+}
+
+// complete 判断全部索引是否已完成。
+func (s *doneSet) complete() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advanceLocked()
+	return s.low == len(s.words)
+}
+
+// advanceLocked 推进低水位，调用方需持有 s.mu。
+func (s *doneSet) advanceLocked() {
+	for s.low < len(s.words) && s.words[s.low] == s.full(s.low) {
+		s.low++
+	}
+}
+
+// save 把低水位和之后的位图原子地写入检查点文件。
+//
+// 文件格式：8 字节文件头、元素数量、低水位，之后是低水位开始的位图，整数均为大端序。
+func (s *doneSet) save(path string) error {
+	s.mu.Lock()
+	s.advanceLocked()
+	// 末尾全零的字不需要写入。
+	end := len(s.words)
+	for end > s.low && s.words[end-1] == 0 {
+		end--
+	}
+	buf := make([]byte, 24+(end-s.low)*8)
+	copy(buf, checkpointMagic[:])
+	binary.BigEndian.PutUint64(buf[8:], uint64(s.n))
+	binary.BigEndian.PutUint64(buf[16:], uint64(s.low*64))
+	for i, w := range s.words[s.low:end] {
+		binary.BigEndian.PutUint64(buf[24+i*8:], w)
+	}
+	s.mu.Unlock()
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// loadCheckpoint 读取检查点文件，文件不存在时返回空的完成集合。
+func loadCheckpoint(path string, n int) (*doneSet, error) {
+	s := &doneSet{n: n, words: make([]uint64, (n+63)/64)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 24 || !bytes.Equal(data[:8], checkpointMagic[:]) || (len(data)-24)%8 != 0 {
+		return nil, fmt.Errorf("bee: read checkpoint %s: %w", path, io.ErrUnexpectedEOF)
+	}
+	low := binary.BigEndian.Uint64(data[16:])
+	if binary.BigEndian.Uint64(data[8:]) != uint64(n) || low%64 != 0 || low/64+uint64(len(data)-24)/8 > uint64(len(s.words)) {
+		return nil, ErrCheckpointMismatch
+	}
+	s.low = int(low / 64)
+	for i := range s.low {
+		s.words[i] = s.full(i)
+	}
+	for i := 24; i < len(data); i += 8 {
+		s.words[s.low+(i-24)/8] = binary.BigEndian.Uint64(data[i:])
+	}
+	// 忽略位图中超出元素数量的位。
+	if last := len(s.words) - 1; last >= 0 {
+		s.words[last] &= s.full(last)
+	}
+	return s, nil
+}
@@ -0,0 +1,70 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestMapResumableResumes(t *testing.T) {
+	const n = 300
+	p := bee.New(context.Background(), 4)
+	defer p.Exit()
+	checkpoint := filepath.Join(t.TempDir(), "map.cp")
+
+	var ok [n]atomic.Int32
+	errBoom := errors.New("boom")
+	err := bee.MapResumable(context.Background(), p, n, checkpoint, func(_ context.Context, i int) error {
+		if i == 150 {
+			return errBoom
+		}
+		ok[i].Add(1)
+		return nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("first MapResumable() = %v, want errBoom", err)
+	}
+	if _, err := os.Stat(checkpoint); err != nil {
+		t.Fatalf("checkpoint after a failure: %v", err)
+	}
+
+	if err := bee.MapResumable(context.Background(), p, n, checkpoint, func(_ context.Context, i int) error {
+		ok[i].Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("second MapResumable() = %v", err)
+	}
+	for i := range ok {
+		if c := ok[i].Load(); c != 1 {
+			t.Fatalf("index %d succeeded %d times, want 1", i, c)
+		}
+	}
+	if _, err := os.Stat(checkpoint); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint left after completion: %v", err)
+	}
+}
+
+func TestMapResumableRejectsOtherBatch(t *testing.T) {
+	p := bee.New(context.Background(), 2)
+	defer p.Exit()
+	checkpoint := filepath.Join(t.TempDir(), "map.cp")
+	errBoom := errors.New("boom")
+	if err := bee.MapResumable(context.Background(), p, 10, checkpoint, func(context.Context, int) error { return errBoom }); !errors.Is(err, errBoom) {
+		t.Fatalf("MapResumable() = %v, want errBoom", err)
+	}
+	var ran atomic.Bool
+	if err := bee.MapResumable(context.Background(), p, 20, checkpoint, func(context.Context, int) error {
+		ran.Store(true)
+		return nil
+	}); err == nil {
+		t.Fatal("MapResumable() with a checkpoint of another size = nil")
+	}
+	if ran.Load() {
+		t.Fatal("MapResumable() ran tasks with a mismatched checkpoint")
+	}
+}
@@ -0,0 +1,94 @@
+package bee
+
+import (
+	"context"
+	"time"
+)
+
+// TaskInfo 描述一个已结束的任务。
+type TaskInfo struct {
+	Pool      string            // 线程池名称
+	Index     int64             // 任务索引
+	Name      string            // 任务名称
+	Tenant    string            // 所属租户
+	Labels    map[string]string // 标签，观察者不应修改
+	Submitter string            // 提交者
+	Priority  int               // 任务优先级
+	Submitted time.Time         // 提交时间
+	Started   time.Time         // 开始运行的时间
+	Finished  time.Time         // 结束时间
+	SlotTime  time.Duration     // 占用槽位的时长，不含让出后重新排队的时间
+	Err       error             // 任务通过 Fail 报告的错误，panic 时为 *PanicError
+}
+
+// QueueTime 返回任务从提交到开始运行的等待时长。
+//
+// 返回值:
+//
+//	time.Duration: 等待时长。
+func (i TaskInfo) QueueTime() time.Duration {
+	return i.Started.Sub(i.Submitted)
+}
+
+// Observer 在任务结束时收到通知，实现需要能被并发调用。
+type Observer interface {
+	TaskDone(info TaskInfo)
+}
+
+// ObserverFunc 将函数适配为 Observer。
+type ObserverFunc func(info TaskInfo)
+
+// TaskDone 调用函数本身。
+func (f ObserverFunc) TaskDone(info TaskInfo) { f(info) }
+
+// WithObserver 添加任务结束时通知的观察者，可以多次使用。
+//
+// 参数:
+//
+//	o Observer: 观察者。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithObserver(o Observer) Option {
+	return func(p *Pool) { p.observers = append(p.observers, o) }
+}
+
+// Fail 将正在运行的任务标记为失败，错误会出现在 TaskInfo.Err 中。
+//
+// 只能在任务函数所在的协程中调用，不在线程池任务中调用时不做任何处理。
+//
+// 参数:
+//
+//	ctx context.Context: 任务函数收到的上下文。
+//	err error: 任务的错误，为 nil 时不做任何处理。
+func Fail(ctx context.Context, err error) {
+	if t := taskFrom(ctx); t != nil && err != nil {
+		t.err = err
+	}
+}
+
+// observe 通知全部观察者任务已结束。
+func (p *Pool) observe(t *task) {
+	if len(p.observers) == 0 {
+		return
+	}
+	finished := time.Now()
+	info := TaskInfo{
+		Pool:      p.name,
+		Index:     t.index,
+		Name:      t.name,
+		Tenant:    t.tenant,
+		Labels:    t.labels,
+		Submitter: t.submitter,
+		Priority:  t.priority,
+		Submitted: t.submitted,
+		Started:   t.startedAt,
+		Finished:  finished,
+		SlotTime:  finished.Sub(t.startedAt) - t.yielded,
+		Err:       t.err,
+	}
+	for _, o := range p.observers {
+		o.TaskDone(info)
+	}
+}
@@ -0,0 +1,9 @@
+module github.com/cnk3x/bee/otelbee
+
+go 1.24.3
+
+require (
+	github.com/cnk3x/bee v0.0.0-20261016142647-2f186ab157b4
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/metric v1.38.0
+)
@@ -0,0 +1,138 @@
+// Package otelbee 将线程池的指标导出到 OpenTelemetry。
+//
+// 每个注册的线程池导出以下指标，带有 bee.pool 属性：
+//
+//	bee.pool.running      正在运行的任务数量（gauge）
+//	bee.pool.capacity     线程池容量（gauge）
+//	bee.pool.queue_depth  等待槽位的任务数量（gauge）
+//	bee.pool.worked       已完成的任务数量（counter）
+//	bee.pool.rejected     未能提交的任务数量（counter）
+//	bee.pool.panics       发生 panic 的任务数量（counter）
+//
+// 作为 bee.Observer 挂到线程池上时，还会记录 bee.task.duration 直方图，带有 bee.pool 和 bee.task 属性。
+package otelbee
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cnk3x/bee"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// 指标属性的键。
+const (
+	AttrPool = attribute.Key("bee.pool") // 线程池名称
+	AttrTask = attribute.Key("bee.task") // 任务名称
+)
+
+// New 使用 meter 创建线程池指标。
+//
+// 参数:
+//
+//	meter metric.Meter: OpenTelemetry meter。
+//
+// 返回值:
+//
+//	*Metrics: 线程池指标。
+//	error: 创建指标失败时返回的错误。
+func New(meter metric.Meter) (*Metrics, error) {
+	m := &Metrics{}
+	var err error
+	if m.running, err = meter.Int64ObservableGauge("bee.pool.running",
+		metric.WithDescription("Number of tasks currently holding a slot."), metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if m.capacity, err = meter.Int64ObservableGauge("bee.pool.capacity",
+		metric.WithDescription("Maximum number of concurrently running tasks."), metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if m.depth, err = meter.Int64ObservableGauge("bee.pool.queue_depth",
+		metric.WithDescription("Number of tasks waiting for a slot."), metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if m.worked, err = meter.Int64ObservableCounter("bee.pool.worked",
+		metric.WithDescription("Number of finished tasks."), metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if m.rejected, err = meter.Int64ObservableCounter("bee.pool.rejected",
+		metric.WithDescription("Number of submissions that were not accepted."), metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if m.panics, err = meter.Int64ObservableCounter("bee.pool.panics",
+		metric.WithDescription("Number of tasks that panicked."), metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if m.duration, err = meter.Float64Histogram("bee.task.duration",
+		metric.WithDescription("Time tasks spent holding a slot."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	m.reg, err = meter.RegisterCallback(m.observe, m.running, m.capacity, m.depth, m.worked, m.rejected, m.panics)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Metrics 是线程池的 OpenTelemetry 指标，实现了 bee.Observer。
+type Metrics struct {
+	running  metric.Int64ObservableGauge
+	capacity metric.Int64ObservableGauge
+	depth    metric.Int64ObservableGauge
+	worked   metric.Int64ObservableCounter
+	rejected metric.Int64ObservableCounter
+	panics   metric.Int64ObservableCounter
+	duration metric.Float64Histogram
+	reg      metric.Registration
+
+	mu    sync.Mutex
+	pools []*bee.Pool
+}
+
+// Register 注册线程池，导出其状态指标。
+//
+// 任务耗时直方图需要在创建线程池时通过 bee.WithObserver(m) 挂上。
+//
+// 参数:
+//
+//	pools ...*bee.Pool: 要注册的线程池。
+func (m *Metrics) Register(pools ...*bee.Pool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools = append(m.pools, pools...)
+}
+
+// TaskDone 记录任务占用槽位的时长。
+func (m *Metrics) TaskDone(info bee.TaskInfo) {
+	m.duration.Record(context.Background(), info.SlotTime.Seconds(),
+		metric.WithAttributes(AttrPool.String(info.Pool), AttrTask.String(info.Name)))
+}
+
+// Close 注销指标回调，之后不再导出线程池状态。
+//
+// 返回值:
+//
+//	error: 注销回调的错误。
+func (m *Metrics) Close() error {
+	return m.reg.Unregister()
+}
+
+// observe 采集全部注册线程池的状态。
+func (m *Metrics) observe(_ context.Context, o metric.Observer) error {
+	m.mu.Lock()
+	pools := append([]*bee.Pool(nil), m.pools...)
+	m.mu.Unlock()
+
+	for _, p := range pools {
+		s := p.Stats()
+		attrs := metric.WithAttributes(AttrPool.String(s.Name))
+		o.ObserveInt64(m.running, int64(s.Running), attrs)
+		o.ObserveInt64(m.capacity, int64(s.Capacity), attrs)
+		o.ObserveInt64(m.depth, int64(s.Waiting), attrs)
+		o.ObserveInt64(m.worked, s.Worked, attrs)
+		o.ObserveInt64(m.rejected, s.Rejected, attrs)
+		o.ObserveInt64(m.panics, s.Panics, attrs)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package bee
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Pressure 是按 Linux PSI（压力阻塞信息）限流的配置。
+//
+// 线程池定期读取 PSI 文件中 some 行的 avg10，即最近 10 秒内有任务因资源不足而阻塞的时间百分比。
+// 任意资源超过阈值时有效容量减半，全部低于阈值后每个周期恢复一个槽位，直到回到线程池容量。
+// 使用 cgroup v2 时可以把路径设置为 cgroup 目录下的 cpu.pressure、memory.pressure 和 io.pressure。
+type Pressure struct {
+	CPUPath    string        // CPU 压力文件，默认为 /proc/pressure/cpu
+	MemoryPath string        // 内存压力文件，默认为 /proc/pressure/memory
+	IOPath     string        // IO 压力文件，默认为 /proc/pressure/io
+	CPU        float64       // CPU 阻塞百分比的阈值，0 表示不检查
+	Memory     float64       // 内存阻塞百分比的阈值，0 表示不检查
+	IO         float64       // IO 阻塞百分比的阈值，0 表示不检查
+	Interval   time.Duration // 采样间隔，默认为 1 秒
+	Min        int           // 有效容量的下限，默认为 1
+}
+
+// pressureState 是压力限流的配置和状态，由 pool.mu 保护。
+type pressureState struct {
+	cfg   Pressure
+	on    bool
+	limit int           // 限流后的有效容量，0 表示未限流
+	stop  chan struct{} // 关闭时停止采样，未在采样时为 nil
+}
+
+// WithPressure 开启按 PSI 压力限流。
+//
+// 读取失败的压力文件视为没有压力，例如内核未开启 PSI 时不会限流。
+// 线程池因空闲而停止时暂停采样并解除限流，恢复运行时重新开始采样。
+//
+// 参数:
+//
+//	cfg Pressure: 限流配置。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithPressure(cfg Pressure) Option {
+	if cfg.CPUPath == "" {
+		cfg.CPUPath = "/proc/pressure/cpu"
+	}
+	if cfg.MemoryPath == "" {
+		cfg.MemoryPath = "/proc/pressure/memory"
+	}
+	if cfg.IOPath == "" {
+		cfg.IOPath = "/proc/pressure/io"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	cfg.Min = max(cfg.Min, 1)
+	return func(p *Pool) { p.pressure = pressureState{cfg: cfg, on: true} }
+}
+
+// startPressure 开始定期采样压力，在 New 应用全部配置之后调用。
+func (p *Pool) startPressure() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.startPressureLocked()
+}
+
+// startPressureLocked 在未采样时开始定期采样压力，调用方需持有 p.mu。
+func (p *Pool) startPressureLocked() {
+	if !p.pressure.on || p.pressure.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	p.pressure.stop = stop
+	cfg := p.pressure.cfg
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.adjustPressure(stop, cfg.stalled())
+			case <-stop:
+				return
+			case <-p.done:
+				return
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// stopPressureLocked 停止采样并解除限流，调用方需持有 p.mu。
+func (p *Pool) stopPressureLocked() {
+	if p.pressure.stop != nil {
+		close(p.pressure.stop)
+		p.pressure.stop = nil
+	}
+	p.pressure.limit = 0
+}
+
+// adjustPressure 根据是否超过阈值调整有效容量，stop 已失效时忽略这次采样。
+func (p *Pool) adjustPressure(stop chan struct{}, stalled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ps := &p.pressure
+	if ps.stop != stop {
+		return
+	}
+	switch {
+	case stalled:
+		ps.limit = max(p.limitLocked()/2, ps.cfg.Min)
+	case ps.limit > 0:
+		ps.limit++
+		if ps.limit >= p.size {
+			ps.limit = 0
+		}
+		p.dispatchLocked()
+	}
+}
+
+// limitLocked 返回当前可以同时运行的任务数量，调用方需持有 p.mu。
+func (p *Pool) limitLocked() int {
+	if p.pressure.limit > 0 {
+		return min(p.size, p.pressure.limit)
+	}
+	return p.size
+}
+
+// stalled 判断是否有资源的压力超过阈值。
+func (c Pressure) stalled() bool {
+	for _, r := range [...]struct {
+		path      string
+		threshold float64
+	}{
+		{c.CPUPath, c.CPU},
+		{c.MemoryPath, c.Memory},
+		{c.IOPath, c.IO},
+	} {
+		if r.threshold <= 0 {
+			continue
+		}
+		if avg, err := readPressure(r.path); err == nil && avg > r.threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// errNoPressure 表示压力文件中没有 some 行。
+var errNoPressure = errors.New("bee: no some line in pressure file")
+
+// readPressure 读取压力文件中 some 行的 avg10。
+//
+// 文件格式为：
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPressure(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := bytes.Fields(sc.Bytes())
+		if len(fields) == 0 || string(fields[0]) != "some" {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if v, ok := bytes.CutPrefix(f, []byte("avg10=")); ok {
+				return strconv.ParseFloat(string(v), 64)
+			}
+		}
+	}
+	return 0, errNoPressure
+}
@@ -0,0 +1,72 @@
+package bee_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// writePressure 写入 some 行的 avg10 为 avg 的压力文件。
+func writePressure(t *testing.T, path string, avg float64) {
+	t.Helper()
+	data := fmt.Sprintf("some avg10=%.2f avg60=0.00 avg300=0.00 total=0\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n", avg)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitThrottle 等待线程池的限流容量满足 cond。
+func waitThrottle(t *testing.T, p *bee.Pool, what string, cond func(int) bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond(p.Stats().Throttle) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, throttle is %d", what, p.Stats().Throttle)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPressureThrottles(t *testing.T) {
+	cpu := filepath.Join(t.TempDir(), "cpu")
+	writePressure(t, cpu, 90)
+	p := bee.New(context.Background(), 8, bee.WithPressure(bee.Pressure{CPUPath: cpu, CPU: 50, Interval: 5 * time.Millisecond, Min: 2}))
+	defer p.Exit()
+
+	waitThrottle(t, p, "throttling to the minimum", func(n int) bool { return n == 2 })
+	writePressure(t, cpu, 1)
+	waitThrottle(t, p, "the throttle to lift", func(n int) bool { return n == 0 })
+}
+
+func TestPressureStopsWhileIdle(t *testing.T) {
+	cpu := filepath.Join(t.TempDir(), "cpu")
+	writePressure(t, cpu, 90)
+	p := bee.New(context.Background(), 8,
+		bee.WithPressure(bee.Pressure{CPUPath: cpu, CPU: 50, Interval: 5 * time.Millisecond}),
+		bee.WithIdleTimeout(50*time.Millisecond, nil),
+	)
+	defer p.Exit()
+
+	waitThrottle(t, p, "throttling", func(n int) bool { return n > 0 })
+	deadline := time.Now().Add(2 * time.Second)
+	for p.State() != bee.StateStopped {
+		if time.Now().After(deadline) {
+			t.Fatal("pool did not stop while idle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := p.Stats().Throttle; n != 0 {
+		t.Fatalf("throttle while stopped = %d, want 0", n)
+	}
+
+	if err := p.Submit(func(context.Context, int64) {}); err != nil {
+		t.Fatalf("Submit() = %v", err)
+	}
+	waitThrottle(t, p, "throttling after waking up", func(n int) bool { return n > 0 })
+}
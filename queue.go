@@ -0,0 +1,371 @@
+package bee
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrQueueFull 表示任务队列已满且无法溢出到磁盘。
+var ErrQueueFull = errors.New("bee: queue full")
+
+// ErrQueueClosed 表示任务队列已关闭，或者因线程池退出、段文件读取失败而停止分发。
+var ErrQueueClosed = errors.New("bee: queue closed")
+
+// QueueOption 定义了任务队列的可选配置。
+type QueueOption func(*Queue)
+
+// QueueCapacity 设置内存队列的容量，默认为 1024。
+//
+// 参数:
+//
+//	n int: 内存中最多保存的任务数量。
+//
+// 返回值:
+//
+//	QueueOption: 任务队列配置。
+func QueueCapacity(n int) QueueOption {
+	return func(q *Queue) { q.capacity = n }
+}
+
+// SpillTo 开启溢出模式：内存队列已满时，将多出的任务写入 dir 目录下的段文件，
+// 在内存队列有空位时按先进先出的顺序读回。
+//
+// 参数:
+//
+//	dir string: 段文件所在的目录。
+//	maxBytes int64: 段文件中尚未读回的记录的最大字节数，超过时拒绝新任务。
+//
+// 返回值:
+//
+//	QueueOption: 任务队列配置。
+func SpillTo(dir string, maxBytes int64) QueueOption {
+	return func(q *Queue) { q.spill = &spill{dir: dir, max: maxBytes} }
+}
+
+// NewQueue 创建一个将已注册任务依次提交到线程池的任务队列。
+//
+// 参数:
+//
+//	p *Pool: 执行任务的线程池。
+//	r *Registry: 任务类型注册表。
+//	opts ...QueueOption: 可选配置。
+//
+// 返回值:
+//
+//	*Queue: 任务队列。
+//	error: 恢复积压任务失败时返回的错误。
+func NewQueue(p *Pool, r *Registry, opts ...QueueOption) (*Queue, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		pool:     p,
+		registry: r,
+		capacity: 1024,
+		ctx:      ctx,
+		cancel:   cancel,
+		stopped:  make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	for _, opt := range opts {
+		opt(q)
+	}
+	if q.restore != "" {
+		if err := q.restoreSnapshot(); err != nil {
+			if q.spill != nil {
+				q.spill.remove()
+			}
+			return nil, err
+		}
+	}
+	go q.loop()
+	return q, nil
+}
+
+// RestoreFrom 在创建队列时从快照文件恢复上次关闭时未执行的任务，恢复后删除快照文件。
+//
+// 快照文件不存在时不做任何处理。
+//
+// 参数:
+//
+//	path string: Shutdown 写入的快照文件路径。
+//
+// 返回值:
+//
+//	QueueOption: 任务队列配置。
+func RestoreFrom(path string) QueueOption {
+	return func(q *Queue) { q.restore = path }
+}
+
+// restoreSnapshot 按 Enqueue 的规则恢复快照中的任务，全部恢复后删除快照文件。
+func (q *Queue) restoreSnapshot() error {
+	jobs, err := loadSnapshot(q.restore)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := q.Enqueue(job); err != nil {
+			return fmt.Errorf("bee: restore %s: %w", q.restore, err)
+		}
+	}
+	if err := os.Remove(q.restore); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Queue 是位于线程池之前的有界任务队列，可选地将溢出的任务暂存到磁盘。
+type Queue struct {
+	pool     *Pool
+	registry *Registry
+	capacity int
+	spill    *spill
+	restore  string // 创建时恢复积压任务的快照文件
+
+	inflight sync.WaitGroup // 已提交到线程池但尚未结束的任务
+
+	ctx     context.Context    // 队列关闭时取消，用于中断等待槽位
+	cancel  context.CancelFunc // 取消 ctx
+	stopped chan struct{}      // 分发协程退出时关闭
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	mem    jobHeap // 内存中的任务
+	seq    uint64  // 入队序号
+	closed bool
+	dead   error // 分发协程停止的原因，不为 nil 时不再接收任务
+}
+
+// Enqueue 将任务加入队列。
+//
+// 内存队列未满且磁盘上没有积压时任务进入内存队列，否则在开启溢出模式时写入段文件，
+// 以保证溢出的任务按先进先出的顺序执行。
+//
+// 参数:
+//
+//	job Job: 已注册类型的任务。
+//
+// 返回值:
+//
+//	error: 任务类型未注册、队列已满或已关闭时返回的错误。
+func (q *Queue) Enqueue(job Job) error {
+	if _, err := q.registry.lookup(job.Type); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if q.dead != nil {
+		return fmt.Errorf("%w: %w", ErrQueueClosed, q.dead)
+	}
+	if q.mem.Len() < q.capacity && (q.spill == nil || q.spill.count == 0) {
+		q.pushLocked(job)
+		return nil
+	}
+	if q.spill == nil {
+		return ErrQueueFull
+	}
+	return q.spill.write(job)
+}
+
+// Len 返回队列中尚未提交到线程池的任务数量，包括溢出到磁盘的任务。
+//
+// 返回值:
+//
+//	int: 排队中的任务数量。
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := q.mem.Len()
+	if q.spill != nil {
+		n += q.spill.count
+	}
+	return n
+}
+
+// Close 关闭队列，停止向线程池提交任务，并删除溢出的段文件。
+//
+// 已提交到线程池的任务不受影响，仍在排队的任务会被丢弃。
+//
+// 返回值:
+//
+//	error: 读取或删除段文件失败时返回的错误，读取失败时保留段文件。
+func (q *Queue) Close() error {
+	if !q.stop() {
+		return nil
+	}
+	_, err := q.drain()
+	return err
+}
+
+// Shutdown 关闭队列，可选地将尚未提交的任务保存到快照文件，并等待已提交的任务结束。
+//
+// 快照中的任务按优先级从高到低、同优先级按入队顺序排列，溢出到磁盘的任务排在最后。
+// 使用 RestoreFrom 创建队列时可以恢复这些任务。
+//
+// 参数:
+//
+//	ctx context.Context: 等待已提交任务结束的期限。
+//	snapshotPath string: 快照文件路径，为空时丢弃未提交的任务。
+//
+// 返回值:
+//
+//	error: 读取段文件、写入快照失败或等待超时时返回的错误。
+//	读取段文件失败时快照只包含已读出的任务，段文件会被保留。
+func (q *Queue) Shutdown(ctx context.Context, snapshotPath string) error {
+	if !q.stop() {
+		return ErrQueueClosed
+	}
+	jobs, drainErr := q.drain()
+	if snapshotPath != "" {
+		if err := saveSnapshot(snapshotPath, jobs); err != nil {
+			return errors.Join(drainErr, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return drainErr
+	case <-ctx.Done():
+		return errors.Join(drainErr, ctx.Err())
+	}
+}
+
+// stop 停止分发协程，返回本次调用是否确实关闭了队列。
+func (q *Queue) stop() bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	q.cancel()
+	<-q.stopped
+	return true
+}
+
+// drain 取出全部未提交的任务并删除段文件，读取段文件失败时保留段文件并返回已读出的任务。
+func (q *Queue) drain() ([]Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, q.mem.Len())
+	for q.mem.Len() > 0 {
+		jobs = append(jobs, heap.Pop(&q.mem).(*queuedJob).job)
+	}
+	if q.spill == nil {
+		return jobs, nil
+	}
+	for q.spill.count > 0 {
+		job, err := q.spill.read()
+		if err != nil {
+			name := q.spill.file.Name()
+			q.spill.file.Close()
+			return jobs, fmt.Errorf("bee: read spill %s: %w", name, err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, q.spill.remove()
+}
+
+// loop 依次将任务提交到线程池，直到队列关闭。
+func (q *Queue) loop() {
+	defer close(q.stopped)
+	for {
+		q.mu.Lock()
+		for q.mem.Len() == 0 && !q.closed && q.dead == nil {
+			q.cond.Wait()
+		}
+		if q.closed || q.dead != nil {
+			q.mu.Unlock()
+			return
+		}
+		it := heap.Pop(&q.mem).(*queuedJob)
+		if err := q.refillLocked(); err != nil {
+			// 段文件损坏时不再分发，剩余的任务留给 Shutdown 保存。
+			q.dead = fmt.Errorf("read spill: %w", err)
+		}
+		q.mu.Unlock()
+
+		job := it.job
+		q.inflight.Add(1)
+		run := q.registry.task(job)
+		err := q.pool.SubmitContext(q.ctx, func(ctx context.Context, index int64) {
+			defer q.inflight.Done()
+			run(ctx, index)
+		}, Priority(job.Priority), Name(job.Type))
+		if err != nil {
+			q.inflight.Done()
+			// 未能提交的任务放回队列，队列关闭或线程池退出后不再分发。
+			q.mu.Lock()
+			heap.Push(&q.mem, it)
+			if !q.closed && q.dead == nil {
+				q.dead = err
+			}
+			q.mu.Unlock()
+			return
+		}
+	}
+}
+
+// pushLocked 将任务加入内存队列，调用方需持有 q.mu。
+func (q *Queue) pushLocked(job Job) {
+	q.seq++
+	heap.Push(&q.mem, &queuedJob{job: job, seq: q.seq})
+	q.cond.Signal()
+}
+
+// refillLocked 从段文件中读回任务，直到内存队列填满，调用方需持有 q.mu。
+func (q *Queue) refillLocked() error {
+	for q.spill != nil && q.spill.count > 0 && q.mem.Len() < q.capacity {
+		job, err := q.spill.read()
+		if err != nil {
+			return err
+		}
+		q.pushLocked(job)
+	}
+	return nil
+}
+
+// queuedJob 是内存队列中的任务。
+type queuedJob struct {
+	job Job
+	seq uint64
+}
+
+// jobHeap 是按优先级从高到低、同优先级按入队顺序排列的任务堆。
+type jobHeap []*queuedJob
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].job.Priority != h[j].job.Priority {
+		return h[i].job.Priority > h[j].job.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *jobHeap) Push(x any) { *h = append(*h, x.(*queuedJob)) }
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
@@ -0,0 +1,188 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestQueueClosedAfterPoolExit(t *testing.T) {
+	r := bee.NewRegistry()
+	r.Register("noop", bee.RawCodec, func(context.Context, bee.Payload) error { return nil })
+	p := bee.New(context.Background(), 1)
+	p.Exit()
+	q, err := bee.NewQueue(p, r)
+	if err != nil {
+		t.Fatalf("NewQueue() = %v", err)
+	}
+	defer q.Close()
+
+	job := bee.Job{Type: "noop"}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := q.Enqueue(job)
+		if errors.Is(err, bee.ErrQueueClosed) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Enqueue() = %v, want nil or ErrQueueClosed", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Enqueue() still accepts jobs after the pool exited")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueueSpillReclaimsReadRecords(t *testing.T) {
+	step := make(chan struct{})
+	ran := make(chan struct{}, 1024)
+	r := bee.NewRegistry()
+	r.Register("step", bee.RawCodec, func(context.Context, bee.Payload) error {
+		<-step
+		ran <- struct{}{}
+		return nil
+	})
+	p := bee.New(context.Background(), 1)
+	defer p.Exit()
+
+	// 每条记录 21 字节，段文件最多保存 4 条未读记录。
+	job := bee.Job{Type: "step", Data: []byte("x")}
+	q, err := bee.NewQueue(p, r, bee.QueueCapacity(1), bee.SpillTo(t.TempDir(), 4*21))
+	if err != nil {
+		t.Fatalf("NewQueue() = %v", err)
+	}
+	defer q.Close()
+	defer close(step)
+
+	// 一个运行中、一个等待槽位、一个在内存中，其余两个写入段文件。
+	for i := range 5 {
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue() #%d = %v", i, err)
+		}
+	}
+	for i := range 100 {
+		step <- struct{}{}
+		<-ran
+		if err := q.Enqueue(job); err != nil {
+			t.Fatalf("Enqueue() after %d completed jobs = %v", i+1, err)
+		}
+	}
+}
+
+func TestQueueShutdownKeepsUnreadableSpill(t *testing.T) {
+	step := make(chan struct{})
+	defer close(step)
+	r := bee.NewRegistry()
+	r.Register("step", bee.RawCodec, func(context.Context, bee.Payload) error {
+		<-step
+		return nil
+	})
+	p := bee.New(context.Background(), 1)
+	defer p.Exit()
+
+	dir := t.TempDir()
+	q, err := bee.NewQueue(p, r, bee.QueueCapacity(1), bee.SpillTo(dir, 1<<20))
+	if err != nil {
+		t.Fatalf("NewQueue() = %v", err)
+	}
+	for i := range 5 {
+		if err := q.Enqueue(bee.Job{Type: "step"}); err != nil {
+			t.Fatalf("Enqueue() #%d = %v", i, err)
+		}
+	}
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segs) != 1 {
+		t.Fatalf("found %d spill segments, want 1", len(segs))
+	}
+	if err := os.Truncate(segs[0], 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx, filepath.Join(dir, "snapshot")); !errors.Is(err, io.EOF) {
+		t.Fatalf("Shutdown() = %v, want the spill read error", err)
+	}
+	if _, err := os.Stat(segs[0]); err != nil {
+		t.Fatalf("spill segment removed after a read error: %v", err)
+	}
+}
+
+func TestQueueRestoreRespectsCapacity(t *testing.T) {
+	ran := make(chan struct{}, 16)
+	step := make(chan struct{})
+	r := bee.NewRegistry()
+	r.Register("step", bee.RawCodec, func(context.Context, bee.Payload) error {
+		<-step
+		ran <- struct{}{}
+		return nil
+	})
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "snapshot")
+
+	// 线程池被占满，任务全部留在队列中并写入快照。
+	block := make(chan struct{})
+	p := bee.New(context.Background(), 1)
+	defer p.Exit()
+	if err := p.SubmitContext(context.Background(), func(context.Context, int64) { <-block }); err != nil {
+		t.Fatalf("SubmitContext() = %v", err)
+	}
+	q, err := bee.NewQueue(p, r)
+	if err != nil {
+		t.Fatalf("NewQueue() = %v", err)
+	}
+	for i := range 5 {
+		if err := q.Enqueue(bee.Job{Type: "step"}); err != nil {
+			t.Fatalf("Enqueue() #%d = %v", i, err)
+		}
+	}
+	if err := q.Shutdown(context.Background(), snapshot); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	close(block)
+	if _, err := bee.NewQueue(p, r, bee.QueueCapacity(2), bee.RestoreFrom(snapshot)); !errors.Is(err, bee.ErrQueueFull) {
+		t.Fatalf("NewQueue() restoring 5 jobs into capacity 2 = %v, want ErrQueueFull", err)
+	}
+	if _, err := os.Stat(snapshot); err != nil {
+		t.Fatalf("snapshot removed after a failed restore: %v", err)
+	}
+
+	q, err = bee.NewQueue(p, r, bee.QueueCapacity(2), bee.SpillTo(dir, 1<<20), bee.RestoreFrom(snapshot))
+	if err != nil {
+		t.Fatalf("NewQueue() restoring with spill = %v", err)
+	}
+	defer q.Close()
+	close(step)
+	for range 5 {
+		select {
+		case <-ran:
+		case <-time.After(2 * time.Second):
+			t.Fatal("restored jobs did not run")
+		}
+	}
+}
+
+func TestQueueRestoreRejectsCorruptSnapshot(t *testing.T) {
+	snapshot := filepath.Join(t.TempDir(), "snapshot")
+	// 记录头声明了约 8 GiB 的类型和参数，文件中只有头部。
+	head := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}
+	if err := os.WriteFile(snapshot, head, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := bee.New(context.Background(), 1)
+	defer p.Exit()
+	if _, err := bee.NewQueue(p, bee.NewRegistry(), bee.RestoreFrom(snapshot)); err == nil {
+		t.Fatal("NewQueue() with a corrupt snapshot = nil")
+	}
+	if _, err := os.Stat(snapshot); err != nil {
+		t.Fatalf("corrupt snapshot removed: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package bee
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownJob 表示任务类型未在注册表中注册。
+var ErrUnknownJob = errors.New("bee: unknown job type")
+
+// Codec 定义了任务参数的编解码方式。
+type Codec interface {
+	// Marshal 将参数编码为字节。
+	Marshal(v any) ([]byte, error)
+	// Unmarshal 将字节解码到 v 中。
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec 使用 encoding/json 编解码参数。
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec 使用 encoding/gob 编解码参数。
+	GobCodec Codec = gobCodec{}
+	// RawCodec 原样传递字节，参数必须是 []byte 或 string，解码目标必须是 *[]byte。
+	RawCodec Codec = rawCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	default:
+		return nil, fmt.Errorf("bee: raw codec cannot marshal %T", v)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("bee: raw codec cannot unmarshal into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Job 是可序列化的任务，由任务类型名称和编码后的参数组成。
+type Job struct {
+	Type     string `json:"type"`               // 任务类型名称
+	Priority int    `json:"priority,omitempty"` // 任务优先级
+	Data     []byte `json:"data"`               // 编码后的参数
+}
+
+// Payload 是交给任务处理函数的参数。
+type Payload struct {
+	Data  []byte // 编码后的参数
+	codec Codec
+}
+
+// Decode 使用任务类型注册的编解码器解码参数。
+//
+// 参数:
+//
+//	v any: 解码目标，通常为指针。
+//
+// 返回值:
+//
+//	error: 解码失败时返回的错误。
+func (p Payload) Decode(v any) error {
+	return p.codec.Unmarshal(p.Data, v)
+}
+
+// Handler 是任务类型的处理函数。
+type Handler func(ctx context.Context, p Payload) error
+
+// jobType 是注册表中的任务类型。
+type jobType struct {
+	codec   Codec
+	handler Handler
+}
+
+// NewRegistry 创建一个空的任务类型注册表。
+//
+// 返回值:
+//
+//	*Registry: 任务类型注册表。
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]jobType)}
+}
+
+// Registry 将任务类型名称映射到处理函数和编解码器。
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]jobType
+}
+
+// Register 注册一个任务类型，重复注册同一名称会 panic。
+//
+// 参数:
+//
+//	name string: 任务类型名称。
+//	codec Codec: 参数的编解码器。
+//	h Handler: 任务处理函数。
+func (r *Registry) Register(name string, codec Codec, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[name]; ok {
+		panic("bee: job type registered twice: " + name)
+	}
+	r.types[name] = jobType{codec: codec, handler: h}
+}
+
+// lookup 查找任务类型。
+func (r *Registry) lookup(name string) (jobType, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	jt, ok := r.types[name]
+	if !ok {
+		return jt, fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	return jt, nil
+}
+
+// NewJob 使用任务类型的编解码器编码参数，创建一个任务。
+//
+// 参数:
+//
+//	name string: 任务类型名称。
+//	v any: 任务参数。
+//
+// 返回值:
+//
+//	Job: 创建的任务。
+//	error: 任务类型未注册或编码失败时返回的错误。
+func (r *Registry) NewJob(name string, v any) (Job, error) {
+	jt, err := r.lookup(name)
+	if err != nil {
+		return Job{}, err
+	}
+	data, err := jt.codec.Marshal(v)
+	if err != nil {
+		return Job{}, err
+	}
+	return Job{Type: name, Data: data}, nil
+}
+
+// Handle 在当前协程中执行任务，处理函数中的 panic 会转换为 PanicError。
+//
+// 参数:
+//
+//	ctx context.Context: 传给处理函数的上下文。
+//	job Job: 要执行的任务。
+//
+// 返回值:
+//
+//	error: 任务类型未注册或处理函数返回的错误。
+func (r *Registry) Handle(ctx context.Context, job Job) error {
+	jt, err := r.lookup(job.Type)
+	if err != nil {
+		return err
+	}
+	return safeCall(ctx, func(ctx context.Context) error {
+		return jt.handler(ctx, Payload{Data: job.Data, codec: jt.codec})
+	})
+}
+
+// Submit 将任务提交到线程池执行，任务的优先级作为提交优先级，任务类型作为任务名称。
+//
+// 处理函数返回的错误通过 Fail 报告给线程池的观察者。
+//
+// 参数:
+//
+//	p *Pool: 执行任务的线程池。
+//	job Job: 要执行的任务。
+//	opts ...TaskOption: 额外的任务选项。
+//
+// 返回值:
+//
+//	error: 任务类型未注册或提交失败时返回的错误。
+func (r *Registry) Submit(p *Pool, job Job, opts ...TaskOption) error {
+	if _, err := r.lookup(job.Type); err != nil {
+		return err
+	}
+	opts = append([]TaskOption{Priority(job.Priority), Name(job.Type)}, opts...)
+	return p.Submit(r.task(job), opts...)
+}
+
+// task 返回执行任务的线程池任务函数。
+func (r *Registry) task(job Job) func(ctx context.Context, index int64) {
+	return func(ctx context.Context, _ int64) {
+		Fail(ctx, r.Handle(ctx, job))
+	}
+}
@@ -0,0 +1,275 @@
+package bee
+
+import (
+	"container/heap"
+	"container/list"
+	"time"
+)
+
+// Ticket 是等待槽位的任务在调度器中的凭证。
+//
+// 调度器只能读取 Ticket 的字段，不能修改。
+// 同一个任务让出槽位后会以新的 Seq 重新入队。
+type Ticket struct {
+	Seq       uint64    // 入队序号，线程池内严格递增
+	Priority  int       // 任务优先级
+	Name      string    // 任务名称
+	Tenant    string    // 所属租户
+	Submitted time.Time // 提交时间
+
+	task *task
+}
+
+// Scheduler 决定等待中的任务获得槽位的先后顺序。
+//
+// 线程池在持有自身的锁时调用调度器，调度器不需要自行加锁，但每个线程池需要使用独立的实例。
+// Pop 只会返回由 Push 加入且尚未被 Pop 或 Remove 取出的凭证。
+type Scheduler interface {
+	// Push 加入一个等待中的任务。
+	Push(t *Ticket)
+	// Pop 取出下一个获得槽位的任务，队列为空时返回 nil。
+	Pop() *Ticket
+	// Len 返回等待中的任务数量。
+	Len() int
+	// Remove 移除一个等待中的任务，返回任务是否在队列中。
+	Remove(t *Ticket) bool
+}
+
+// Peeker 是调度器的可选接口，返回下一个将被 Pop 取出的任务而不移除它。
+//
+// 实现了 Peeker 的调度器中，Yield 只在下一个任务的优先级更高时让出槽位；
+// 未实现时只要有任务等待就让出槽位。
+type Peeker interface {
+	// Peek 返回下一个将被 Pop 取出的任务，队列为空时返回 nil。
+	Peek() *Ticket
+}
+
+// WithScheduler 设置线程池的调度器，默认使用 NewPriorityScheduler。
+//
+// 参数:
+//
+//	s Scheduler: 调度器，必须为空并且只供这个线程池使用。
+//
+// 返回值:
+//
+//	Option: 线程池配置。
+func WithScheduler(s Scheduler) Option {
+	return func(p *Pool) { p.waiting = s }
+}
+
+// NewFIFOScheduler 创建按入队顺序分配槽位的调度器，忽略优先级。
+//
+// 返回值:
+//
+//	Scheduler: 调度器。
+func NewFIFOScheduler() Scheduler {
+	return &listScheduler{elems: map[*Ticket]*list.Element{}}
+}
+
+// NewLIFOScheduler 创建优先分配给最后入队任务的调度器，忽略优先级。
+//
+// 返回值:
+//
+//	Scheduler: 调度器。
+func NewLIFOScheduler() Scheduler {
+	return &listScheduler{elems: map[*Ticket]*list.Element{}, lifo: true}
+}
+
+// NewPriorityScheduler 创建按优先级从高到低、同优先级按入队顺序分配槽位的调度器。
+//
+// 返回值:
+//
+//	Scheduler: 调度器。
+func NewPriorityScheduler() Scheduler {
+	return &priorityScheduler{index: map[*Ticket]int{}}
+}
+
+// NewFairScheduler 创建在租户之间轮流分配槽位的调度器。
+//
+// 每个租户的任务按优先级和入队顺序排列，没有设置租户的任务视为同一个租户。
+//
+// 返回值:
+//
+//	Scheduler: 调度器。
+func NewFairScheduler() Scheduler {
+	return &fairScheduler{tenants: map[string]*fairTenant{}}
+}
+
+// listScheduler 是按入队顺序排列的调度器。
+type listScheduler struct {
+	list  list.List
+	elems map[*Ticket]*list.Element
+	lifo  bool // 是否从队尾取出
+}
+
+func (s *listScheduler) Push(t *Ticket) { s.elems[t] = s.list.PushBack(t) }
+
+func (s *listScheduler) Pop() *Ticket {
+	e := s.next()
+	if e == nil {
+		return nil
+	}
+	t := s.list.Remove(e).(*Ticket)
+	delete(s.elems, t)
+	return t
+}
+
+func (s *listScheduler) Len() int { return s.list.Len() }
+
+func (s *listScheduler) Remove(t *Ticket) bool {
+	e, ok := s.elems[t]
+	if ok {
+		s.list.Remove(e)
+		delete(s.elems, t)
+	}
+	return ok
+}
+
+func (s *listScheduler) Peek() *Ticket {
+	if e := s.next(); e != nil {
+		return e.Value.(*Ticket)
+	}
+	return nil
+}
+
+// next 返回下一个取出的元素。
+func (s *listScheduler) next() *list.Element {
+	if s.lifo {
+		return s.list.Back()
+	}
+	return s.list.Front()
+}
+
+// priorityScheduler 是按优先级排列的调度器。
+type priorityScheduler struct {
+	heap  []*Ticket
+	index map[*Ticket]int // 凭证在堆中的位置
+}
+
+func (s *priorityScheduler) Push(t *Ticket) { heap.Push((*ticketHeap)(s), t) }
+
+func (s *priorityScheduler) Pop() *Ticket {
+	if len(s.heap) == 0 {
+		return nil
+	}
+	return heap.Pop((*ticketHeap)(s)).(*Ticket)
+}
+
+func (s *priorityScheduler) Len() int { return len(s.heap) }
+
+func (s *priorityScheduler) Remove(t *Ticket) bool {
+	i, ok := s.index[t]
+	if ok {
+		heap.Remove((*ticketHeap)(s), i)
+	}
+	return ok
+}
+
+func (s *priorityScheduler) Peek() *Ticket {
+	if len(s.heap) == 0 {
+		return nil
+	}
+	return s.heap[0]
+}
+
+// ticketHeap 实现 heap.Interface，优先级从高到低、同优先级按入队顺序排列。
+type ticketHeap priorityScheduler
+
+func (h *ticketHeap) Len() int { return len(h.heap) }
+
+func (h *ticketHeap) Less(i, j int) bool {
+	a, b := h.heap[i], h.heap[j]
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.Seq < b.Seq
+}
+
+func (h *ticketHeap) Swap(i, j int) {
+	h.heap[i], h.heap[j] = h.heap[j], h.heap[i]
+	h.index[h.heap[i]] = i
+	h.index[h.heap[j]] = j
+}
+
+func (h *ticketHeap) Push(x any) {
+	t := x.(*Ticket)
+	h.index[t] = len(h.heap)
+	h.heap = append(h.heap, t)
+}
+
+func (h *ticketHeap) Pop() any {
+	n := len(h.heap)
+	t := h.heap[n-1]
+	h.heap[n-1] = nil
+	h.heap = h.heap[:n-1]
+	delete(h.index, t)
+	return t
+}
+
+// fairScheduler 是在租户之间轮流分配的调度器。
+type fairScheduler struct {
+	tenants map[string]*fairTenant
+	ring    list.List // 有任务等待的租户，队首的租户下一个获得槽位
+	n       int
+}
+
+// fairTenant 是一个租户的等待队列。
+type fairTenant struct {
+	queue priorityScheduler
+	elem  *list.Element // 在轮转队列中的位置
+}
+
+func (s *fairScheduler) Push(t *Ticket) {
+	q, ok := s.tenants[t.Tenant]
+	if !ok {
+		q = &fairTenant{queue: priorityScheduler{index: map[*Ticket]int{}}}
+		q.elem = s.ring.PushBack(t.Tenant)
+		s.tenants[t.Tenant] = q
+	}
+	q.queue.Push(t)
+	s.n++
+}
+
+func (s *fairScheduler) Pop() *Ticket {
+	e := s.ring.Front()
+	if e == nil {
+		return nil
+	}
+	q := s.tenants[e.Value.(string)]
+	t := q.queue.Pop()
+	s.n--
+	// 取出后租户排到轮转队列的末尾。
+	if q.queue.Len() == 0 {
+		s.drop(t.Tenant, q)
+	} else {
+		s.ring.MoveToBack(e)
+	}
+	return t
+}
+
+func (s *fairScheduler) Len() int { return s.n }
+
+func (s *fairScheduler) Remove(t *Ticket) bool {
+	q, ok := s.tenants[t.Tenant]
+	if !ok || !q.queue.Remove(t) {
+		return false
+	}
+	s.n--
+	if q.queue.Len() == 0 {
+		s.drop(t.Tenant, q)
+	}
+	return true
+}
+
+func (s *fairScheduler) Peek() *Ticket {
+	if e := s.ring.Front(); e != nil {
+		return s.tenants[e.Value.(string)].queue.Peek()
+	}
+	return nil
+}
+
+// drop 移除没有任务等待的租户。
+func (s *fairScheduler) drop(tenant string, q *fairTenant) {
+	s.ring.Remove(q.elem)
+	delete(s.tenants, tenant)
+}
@@ -0,0 +1,96 @@
+package bee_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+	"github.com/cnk3x/bee/beetest"
+)
+
+func TestSchedulerConformance(t *testing.T) {
+	for name, newScheduler := range map[string]func() bee.Scheduler{
+		"FIFO":     bee.NewFIFOScheduler,
+		"LIFO":     bee.NewLIFOScheduler,
+		"Priority": bee.NewPriorityScheduler,
+		"Fair":     bee.NewFairScheduler,
+	} {
+		t.Run(name, func(t *testing.T) { beetest.RunSchedulerConformance(t, newScheduler) })
+	}
+}
+
+// waiter 是按顺序提交到暂停的线程池中的任务。
+type waiter struct {
+	name     string
+	priority int
+	tenant   string
+}
+
+// runOrder 在暂停的单槽位线程池中依次提交任务，恢复后返回任务的运行顺序。
+func runOrder(t *testing.T, s bee.Scheduler, waiters ...waiter) []string {
+	t.Helper()
+	p := bee.New(context.Background(), 1, bee.WithScheduler(s))
+	defer p.Exit()
+	p.Pause()
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	for i, w := range waiters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Submit(func(context.Context, int64) {
+				mu.Lock()
+				order = append(order, w.name)
+				mu.Unlock()
+			}, bee.Priority(w.priority), bee.Tenant(w.tenant))
+			if err != nil {
+				t.Errorf("Submit(%s) = %v", w.name, err)
+			}
+		}()
+		deadline := time.Now().Add(2 * time.Second)
+		for p.Stats().Waiting != i+1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s is not waiting", w.name)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	p.Resume()
+	wg.Wait()
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	waiters := []waiter{
+		{name: "a1", priority: 0, tenant: "a"},
+		{name: "a2", priority: 1, tenant: "a"},
+		{name: "a3", priority: 0, tenant: "a"},
+		{name: "b1", priority: 0, tenant: "b"},
+	}
+	for _, tc := range []struct {
+		name string
+		s    bee.Scheduler
+		want []string
+	}{
+		{"FIFO", bee.NewFIFOScheduler(), []string{"a1", "a2", "a3", "b1"}},
+		{"LIFO", bee.NewLIFOScheduler(), []string{"b1", "a3", "a2", "a1"}},
+		{"Priority", bee.NewPriorityScheduler(), []string{"a2", "a1", "a3", "b1"}},
+		{"Fair", bee.NewFairScheduler(), []string{"a2", "b1", "a1", "a3"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := runOrder(t, tc.s, waiters...); !slices.Equal(got, tc.want) {
+				t.Fatalf("run order = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package bee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Drainer 是可以优雅关闭的组件，*Pool 实现了该接口。
+type Drainer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// DrainerFunc 将函数适配为 Drainer。
+type DrainerFunc func(ctx context.Context) error
+
+// Shutdown 调用函数本身。
+func (f DrainerFunc) Shutdown(ctx context.Context) error { return f(ctx) }
+
+// NewShutdownGroup 创建一个按依赖顺序关闭多个组件的关闭组。
+//
+// 返回值:
+//
+//	*ShutdownGroup: 关闭组。
+func NewShutdownGroup() *ShutdownGroup {
+	return &ShutdownGroup{index: map[string]int{}}
+}
+
+// ShutdownGroup 按依赖顺序关闭多个线程池或其他组件。
+//
+// 一个成员只有在它依赖的上游成员全部关闭之后才开始关闭，互不依赖的成员并发关闭。
+type ShutdownGroup struct {
+	mu      sync.Mutex
+	members []*member
+	index   map[string]int
+}
+
+// member 是关闭组的成员。
+type member struct {
+	name  string
+	d     Drainer
+	grace time.Duration
+	deps  []string
+}
+
+// Add 向关闭组添加一个成员。
+//
+// upstream 是向该成员提交任务的上游成员，它们会先于该成员关闭，
+// 例如 processing 依赖 ingest 时，ingest 排空后才开始关闭 processing。
+//
+// 参数:
+//
+//	name string: 成员名称，在关闭组内唯一。
+//	d Drainer: 要关闭的组件。
+//	grace time.Duration: 该成员关闭的时间预算，0 表示只受整体期限限制。
+//	upstream ...string: 上游成员的名称。
+func (g *ShutdownGroup) Add(name string, d Drainer, grace time.Duration, upstream ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m := &member{name: name, d: d, grace: grace, deps: upstream}
+	if i, ok := g.index[name]; ok {
+		g.members[i] = m
+		return
+	}
+	g.index[name] = len(g.members)
+	g.members = append(g.members, m)
+}
+
+// Shutdown 按依赖顺序关闭全部成员。
+//
+// 每个成员的关闭时间不超过它自己的预算，也不超过 ctx 的整体期限；
+// 某个成员关闭超时或失败时，下游成员仍会继续关闭。
+//
+// 参数:
+//
+//	ctx context.Context: 整体期限。
+//
+// 返回值:
+//
+//	error: 依赖关系无效时返回的错误，或各成员关闭错误的合并。
+func (g *ShutdownGroup) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	members := append([]*member(nil), g.members...)
+	index := maps.Clone(g.index)
+	g.mu.Unlock()
+
+	if err := checkDeps(members, index); err != nil {
+		return err
+	}
+
+	done := make([]chan struct{}, len(members))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	errs := make([]error, len(members))
+
+	var wg sync.WaitGroup
+	for i, m := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			for _, dep := range m.deps {
+				<-done[index[dep]]
+			}
+			mctx, cancel := ctx, context.CancelFunc(func() {})
+			if m.grace > 0 {
+				mctx, cancel = context.WithTimeout(ctx, m.grace)
+			}
+			defer cancel()
+			if err := m.d.Shutdown(mctx); err != nil {
+				errs[i] = fmt.Errorf("bee: shutdown %s: %w", m.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// checkDeps 检查依赖的成员是否存在以及是否有循环依赖。
+func checkDeps(members []*member, index map[string]int) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(members))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("bee: shutdown dependency cycle at %s", members[i].name)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, dep := range members[i].deps {
+			j, ok := index[dep]
+			if !ok {
+				return fmt.Errorf("bee: %s depends on unknown member %s", members[i].name, dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range members {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
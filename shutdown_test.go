@@ -0,0 +1,105 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestShutdownGroupOrder(t *testing.T) {
+	var rec recorder
+	drainer := func(name string) bee.Drainer {
+		return bee.DrainerFunc(func(context.Context) error {
+			rec.add(name + " start")
+			time.Sleep(5 * time.Millisecond)
+			rec.add(name + " done")
+			return nil
+		})
+	}
+	g := bee.NewShutdownGroup()
+	g.Add("store", drainer("store"), 0, "processing", "audit")
+	g.Add("processing", drainer("processing"), 0, "ingest")
+	g.Add("ingest", drainer("ingest"), 0)
+	g.Add("audit", drainer("audit"), 0)
+
+	// 关闭期间添加成员不影响本次关闭。
+	stop := make(chan struct{})
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				g.Add("late-"+strconv.Itoa(i), drainer("late"), 0)
+			}
+		}
+	}()
+	err := g.Shutdown(context.Background())
+	close(stop)
+	if err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	events := rec.get()
+	before := func(a, b string) {
+		t.Helper()
+		i, j := slices.Index(events, a), slices.Index(events, b)
+		if i < 0 || j < 0 || i > j {
+			t.Fatalf("%q not before %q in %v", a, b, events)
+		}
+	}
+	before("ingest done", "processing start")
+	before("processing done", "store start")
+	before("audit done", "store start")
+}
+
+func TestShutdownGroupGrace(t *testing.T) {
+	var rec recorder
+	g := bee.NewShutdownGroup()
+	g.Add("stuck", bee.DrainerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), 20*time.Millisecond)
+	g.Add("next", bee.DrainerFunc(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("downstream member lost the overall deadline")
+		}
+		rec.add("next")
+		return nil
+	}), 0, "stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := g.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Shutdown() took %v, the stuck member's grace is 20ms", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stuck") {
+		t.Fatalf("Shutdown() = %v, want the stuck member's deadline error", err)
+	}
+	if got := rec.get(); !slices.Equal(got, []string{"next"}) {
+		t.Fatalf("downstream events = %v, want [next]", got)
+	}
+}
+
+func TestShutdownGroupInvalidDeps(t *testing.T) {
+	g := bee.NewShutdownGroup()
+	nop := bee.DrainerFunc(func(context.Context) error { return nil })
+	g.Add("a", nop, 0, "b")
+	g.Add("b", nop, 0, "a")
+	if err := g.Shutdown(context.Background()); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("Shutdown() with a cycle = %v", err)
+	}
+	g = bee.NewShutdownGroup()
+	g.Add("a", nop, 0, "missing")
+	if err := g.Shutdown(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Fatalf("Shutdown() with an unknown dependency = %v", err)
+	}
+}
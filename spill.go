@@ -0,0 +1,191 @@
+package bee
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// spillHeader 是段文件中每条记录头部的长度：类型长度、优先级、参数长度。
+const spillHeader = 4 + 8 + 4
+
+// errCorruptRecord 表示段文件或快照中记录的长度超出了文件的剩余字节数。
+var errCorruptRecord = errors.New("bee: corrupt queue record")
+
+// spill 是溢出任务的段文件，记录按写入顺序读出。
+//
+// 读出的记录在全部读完时截断文件回收，写满时把未读的记录移到文件开头回收。
+type spill struct {
+	dir      string
+	max      int64 // 未读记录的最大字节数
+	file     *os.File
+	readOff  int64 // 下一条待读记录的偏移
+	writeOff int64 // 下一条记录的写入偏移
+	count    int   // 未读记录数
+}
+
+// write 在段文件末尾追加一条记录。
+func (s *spill) write(job Job) error {
+	size := int64(spillHeader + len(job.Type) + len(job.Data))
+	if s.writeOff-s.readOff+size > s.max {
+		return ErrQueueFull
+	}
+	if s.writeOff+size > s.max {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp(s.dir, "bee-spill-*.seg")
+		if err != nil {
+			return err
+		}
+		s.file = f
+	}
+
+	if _, err := s.file.WriteAt(encodeJob(job), s.writeOff); err != nil {
+		return err
+	}
+	s.writeOff += size
+	s.count++
+	return nil
+}
+
+// read 读出最早写入的一条记录，全部读完后截断段文件。
+func (s *spill) read() (Job, error) {
+	r := io.NewSectionReader(s.file, s.readOff, s.writeOff-s.readOff)
+	job, n, err := decodeJob(r, s.writeOff-s.readOff)
+	if err != nil {
+		return Job{}, err
+	}
+	s.readOff += n
+	s.count--
+	if s.count == 0 {
+		s.readOff, s.writeOff = 0, 0
+		s.file.Truncate(0)
+	}
+	return job, nil
+}
+
+// compact 把未读的记录移到文件开头并截断文件。
+func (s *spill) compact() error {
+	// 写入位置总在读取位置之前，按顺序复制不会覆盖尚未复制的记录。
+	n, err := io.Copy(io.NewOffsetWriter(s.file, 0), io.NewSectionReader(s.file, s.readOff, s.writeOff-s.readOff))
+	if err != nil {
+		return err
+	}
+	if err := s.file.Truncate(n); err != nil {
+		return err
+	}
+	s.readOff, s.writeOff = 0, n
+	return nil
+}
+
+// remove 关闭并删除段文件。
+func (s *spill) remove() error {
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file, s.readOff, s.writeOff, s.count = nil, 0, 0, 0
+	return err
+}
+
+// encodeJob 将任务编码为一条记录。
+func encodeJob(job Job) []byte {
+	buf := make([]byte, spillHeader+len(job.Type)+len(job.Data))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(job.Type)))
+	binary.BigEndian.PutUint64(buf[4:], uint64(int64(job.Priority)))
+	binary.BigEndian.PutUint32(buf[12:], uint32(len(job.Data)))
+	copy(buf[spillHeader:], job.Type)
+	copy(buf[spillHeader+len(job.Type):], job.Data)
+	return buf
+}
+
+// decodeJob 从 r 中读出一条记录，返回任务和记录的字节数。
+//
+// remain 是 r 中剩余的字节数，记录头中的长度超出时返回错误而不按该长度分配内存。
+func decodeJob(r io.Reader, remain int64) (Job, int64, error) {
+	var head [spillHeader]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return Job{}, 0, err
+	}
+	typeLen := int64(binary.BigEndian.Uint32(head[0:]))
+	priority := int(int64(binary.BigEndian.Uint64(head[4:])))
+	dataLen := int64(binary.BigEndian.Uint32(head[12:]))
+	if typeLen+dataLen > remain-spillHeader {
+		return Job{}, 0, errCorruptRecord
+	}
+
+	body := make([]byte, typeLen+dataLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Job{}, 0, err
+	}
+	job := Job{Type: string(body[:typeLen]), Priority: priority, Data: body[typeLen:]}
+	return job, int64(spillHeader + len(body)), nil
+}
+
+// saveSnapshot 将任务按顺序写入快照文件，先写临时文件再重命名，避免留下不完整的快照。
+func saveSnapshot(path string, jobs []Job) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	for _, job := range jobs {
+		if _, err := w.Write(encodeJob(job)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// loadSnapshot 按顺序读出快照文件中的任务，文件不存在时返回空列表。
+func loadSnapshot(path string) ([]Job, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	r := bufio.NewReader(f)
+	for remain := fi.Size(); ; {
+		job, n, err := decodeJob(r, remain)
+		if errors.Is(err, io.EOF) {
+			return jobs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bee: load snapshot %s: %w", path, err)
+		}
+		remain -= n
+		jobs = append(jobs, job)
+	}
+}
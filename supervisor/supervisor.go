@@ -0,0 +1,430 @@
+// Package supervisor 提供 Erlang 风格的长期运行服务监督器。
+//
+// 监督器按顺序启动子服务，在子服务返回或 panic 时按照重启策略重启，
+// 并在重启次数超过限制时停止全部子服务。
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// ErrTooManyRestarts 表示在限定周期内重启次数超过了上限，监督器已停止全部子服务。
+var ErrTooManyRestarts = errors.New("supervisor: too many restarts")
+
+// Strategy 定义了子服务退出时的重启策略。
+type Strategy int
+
+const (
+	// OneForOne 只重启退出的子服务。
+	OneForOne Strategy = iota
+	// OneForAll 停止并重启全部子服务。
+	OneForAll
+	// RestForOne 停止并重启退出的子服务以及在它之后启动的子服务。
+	RestForOne
+)
+
+// Restart 定义了单个子服务在何种情况下需要重启。
+type Restart int
+
+const (
+	// Permanent 子服务无论如何退出都会被重启。
+	Permanent Restart = iota
+	// Transient 子服务仅在返回错误或 panic 时被重启。
+	Transient
+	// Temporary 子服务退出后不再重启。
+	Temporary
+)
+
+// State 表示子服务的运行状态。
+type State string
+
+const (
+	StateIdle     State = "idle"     // 尚未启动
+	StateWaiting  State = "waiting"  // 等待线程池槽位
+	StateRunning  State = "running"  // 正在运行
+	StateBackoff  State = "backoff"  // 等待重启
+	StateStopped  State = "stopped"  // 已停止
+	StateFinished State = "finished" // 已退出且不再重启
+)
+
+// Child 描述一个被监督的子服务。
+type Child struct {
+	Name     string                          // 子服务名称
+	Run      func(ctx context.Context) error // 子服务主循环，ctx 取消时应尽快返回
+	Restart  Restart                         // 重启方式
+	Shutdown time.Duration                   // 停止时等待子服务退出的最长时间，0 表示一直等待
+}
+
+// ChildStatus 是子服务的状态快照。
+type ChildStatus struct {
+	Name      string    // 子服务名称
+	State     State     // 运行状态
+	Restarts  int       // 累计重启次数
+	LastError error     // 最近一次退出的错误
+	Since     time.Time // 进入当前状态的时间
+}
+
+// PanicError 包装了子服务运行时发生的 panic。
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("supervisor: child panic: %v", e.Value) }
+
+// Option 定义了监督器的可选配置。
+type Option func(*Supervisor)
+
+// WithIntensity 设置重启强度限制：period 时间内最多重启 max 次。
+//
+// 参数:
+//
+//	max int: 周期内允许的最大重启次数。
+//	period time.Duration: 统计周期。
+//
+// 返回值:
+//
+//	Option: 监督器配置。
+func WithIntensity(max int, period time.Duration) Option {
+	return func(s *Supervisor) { s.maxRestarts, s.period = max, period }
+}
+
+// WithBackoff 设置重启前的退避时间，随周期内的重启次数按指数增长。
+//
+// 参数:
+//
+//	min time.Duration: 首次重启前的等待时间。
+//	max time.Duration: 等待时间的上限。
+//
+// 返回值:
+//
+//	Option: 监督器配置。
+func WithBackoff(min, max time.Duration) Option {
+	return func(s *Supervisor) { s.minBackoff, s.maxBackoff = min, max }
+}
+
+// WithPool 设置运行子服务的线程池，未设置时每个子服务使用独立的协程。
+//
+// 参数:
+//
+//	p *bee.Pool: 线程池，容量应不小于子服务数量。
+//
+// 返回值:
+//
+//	Option: 监督器配置。
+func WithPool(p *bee.Pool) Option {
+	return func(s *Supervisor) { s.pool = p }
+}
+
+// New 创建一个监督器。
+//
+// 参数:
+//
+//	strategy Strategy: 重启策略。
+//	children []Child: 子服务，按顺序启动、逆序停止。
+//	opts ...Option: 可选配置。
+//
+// 返回值:
+//
+//	*Supervisor: 监督器实例。
+func New(strategy Strategy, children []Child, opts ...Option) *Supervisor {
+	s := &Supervisor{
+		strategy:    strategy,
+		maxRestarts: 3,
+		period:      5 * time.Second,
+		minBackoff:  100 * time.Millisecond,
+		maxBackoff:  10 * time.Second,
+		exits:       make(chan exit),
+		stopped:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for _, c := range children {
+		s.children = append(s.children, &child{spec: c, status: ChildStatus{Name: c.Name, State: StateIdle, Since: time.Now()}})
+	}
+	return s
+}
+
+// Supervisor 监督一组长期运行的子服务。
+type Supervisor struct {
+	strategy    Strategy
+	maxRestarts int
+	period      time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	pool        *bee.Pool
+
+	children []*child
+	restarts []time.Time // 周期内的重启时间
+	exits    chan exit
+	stopped  chan struct{} // Run 返回时关闭
+
+	mu     sync.Mutex // 保护子服务状态
+	cancel context.CancelFunc
+}
+
+// child 是子服务的运行时状态。
+type child struct {
+	spec   Child
+	gen    int                // 启动代数，用于忽略已停止实例的退出事件
+	cancel context.CancelFunc // 停止当前实例
+	done   chan struct{}      // 当前实例退出时关闭
+	status ChildStatus
+}
+
+// exit 是子服务退出事件。
+type exit struct {
+	i   int
+	gen int
+	err error
+}
+
+// Run 按顺序启动全部子服务并监督它们，直到 ctx 取消或重启次数超过限制。
+//
+// 返回前会按逆序停止全部子服务。
+//
+// 参数:
+//
+//	ctx context.Context: 控制监督器生命周期的上下文。
+//
+// 返回值:
+//
+//	error: ctx 取消时返回 nil，重启次数超限时返回 ErrTooManyRestarts。
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer close(s.stopped)
+	defer cancel()
+
+	for i := range s.children {
+		s.start(ctx, i)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.stopFrom(0)
+			return nil
+		case e := <-s.exits:
+			if err := s.handle(ctx, e); err != nil {
+				s.stopFrom(0)
+				return err
+			}
+		}
+	}
+}
+
+// Stop 停止监督器并等待全部子服务退出。
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-s.stopped
+	}
+}
+
+// Status 返回全部子服务的状态快照，顺序与启动顺序一致。
+//
+// 返回值:
+//
+//	[]ChildStatus: 子服务状态。
+func (s *Supervisor) Status() []ChildStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ChildStatus, len(s.children))
+	for i, c := range s.children {
+		out[i] = c.status
+	}
+	return out
+}
+
+// handle 处理子服务退出事件，必要时按策略重启。
+func (s *Supervisor) handle(ctx context.Context, e exit) error {
+	c := s.children[e.i]
+	if e.gen != c.gen || ctx.Err() != nil {
+		return nil
+	}
+
+	restart := c.spec.Restart == Permanent || (c.spec.Restart == Transient && e.err != nil)
+	s.setState(c, StateFinished, e.err)
+	if !restart {
+		return nil
+	}
+
+	n, ok := s.allowRestart()
+	if !ok {
+		return ErrTooManyRestarts
+	}
+
+	// 需要一起重启的子服务范围。
+	from, to := e.i, e.i+1
+	switch s.strategy {
+	case OneForAll:
+		from, to = 0, len(s.children)
+	case RestForOne:
+		to = len(s.children)
+	}
+	for i := to - 1; i >= from; i-- {
+		if i != e.i {
+			s.stop(s.children[i])
+		}
+	}
+
+	s.setState(c, StateBackoff, e.err)
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(s.backoff(n)):
+	}
+
+	for i := from; i < to; i++ {
+		cc := s.children[i]
+		if i != e.i && s.state(cc) == StateFinished {
+			continue // 已经正常结束的子服务不随其他子服务重启
+		}
+		s.mu.Lock()
+		cc.status.Restarts++
+		s.mu.Unlock()
+		s.start(ctx, i)
+	}
+	return nil
+}
+
+// allowRestart 记录一次重启并检查是否超过重启强度，返回周期内的重启次数。
+func (s *Supervisor) allowRestart() (int, bool) {
+	now := time.Now()
+	kept := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.period {
+			kept = append(kept, t)
+		}
+	}
+	s.restarts = append(kept, now)
+	return len(s.restarts), len(s.restarts) <= s.maxRestarts
+}
+
+// backoff 返回第 n 次重启前的等待时间。
+func (s *Supervisor) backoff(n int) time.Duration {
+	d := s.minBackoff
+	for i := 1; i < n && d < s.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.maxBackoff)
+}
+
+// start 启动第 i 个子服务的新实例。
+//
+// 使用线程池时，子服务在获得槽位之前处于 StateWaiting。
+func (s *Supervisor) start(ctx context.Context, i int) {
+	c := s.children[i]
+	cctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	c.gen++
+	gen := c.gen
+	s.mu.Unlock()
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	done := c.done
+	s.setState(c, StateWaiting, c.status.LastError)
+
+	run := func() {
+		defer cancel()
+		s.started(c, gen)
+		err := s.call(cctx, c.spec.Run)
+		close(done)
+		select {
+		case s.exits <- exit{i: i, gen: gen, err: err}:
+		case <-s.stopped:
+		}
+	}
+	if s.pool == nil {
+		go run()
+		return
+	}
+	go func() {
+		if err := s.pool.SubmitContext(cctx, func(context.Context, int64) { run() }); err != nil {
+			cancel()
+			close(done)
+			select {
+			case s.exits <- exit{i: i, gen: gen, err: err}:
+			case <-s.stopped:
+			}
+		}
+	}()
+}
+
+// call 运行子服务并将 panic 转换为错误。
+func (s *Supervisor) call(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r}
+		}
+	}()
+	return f(ctx)
+}
+
+// stopFrom 按逆序停止第 from 个及之后的全部子服务。
+func (s *Supervisor) stopFrom(from int) {
+	for i := len(s.children) - 1; i >= from; i-- {
+		s.stop(s.children[i])
+	}
+}
+
+// stop 停止子服务的当前实例并等待其退出。
+func (s *Supervisor) stop(c *child) {
+	s.mu.Lock()
+	active := c.cancel != nil && (c.status.State == StateRunning || c.status.State == StateWaiting)
+	if active {
+		c.gen++ // 忽略该实例之后的退出事件
+	}
+	s.mu.Unlock()
+	if !active {
+		return
+	}
+	c.cancel()
+	if c.spec.Shutdown > 0 {
+		select {
+		case <-c.done:
+		case <-time.After(c.spec.Shutdown):
+		}
+	} else {
+		<-c.done
+	}
+	s.setState(c, StateStopped, c.status.LastError)
+}
+
+// started 在子服务实例开始运行时更新状态，实例已被停止时不做处理。
+func (s *Supervisor) started(c *child, gen int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.gen == gen {
+		c.status.State = StateRunning
+		c.status.Since = time.Now()
+	}
+}
+
+// state 返回子服务的运行状态。
+func (s *Supervisor) state(c *child) State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return c.status.State
+}
+
+// setState 更新子服务状态。
+func (s *Supervisor) setState(c *child, state State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.status.State = state
+	c.status.LastError = err
+	c.status.Since = time.Now()
+}
@@ -0,0 +1,55 @@
+package supervisor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+	"github.com/cnk3x/bee/supervisor"
+)
+
+func TestStopWhileWaitingForPool(t *testing.T) {
+	block := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+	s := supervisor.New(supervisor.OneForOne, []supervisor.Child{
+		{Name: "a", Run: block},
+		{Name: "b", Run: block},
+	}, supervisor.WithPool(bee.New(context.Background(), 1)))
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st := s.Status()
+		if states := st[0].State + "," + st[1].State; states == "running,waiting" || states == "waiting,running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Status() = %+v, want one running and one waiting", st)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop() did not return")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	for _, st := range s.Status() {
+		if st.State != supervisor.StateStopped {
+			t.Fatalf("child %s state = %s, want stopped", st.Name, st.State)
+		}
+	}
+}
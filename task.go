@@ -0,0 +1,153 @@
+package bee
+
+import (
+	"context"
+	"time"
+)
+
+// TaskOption 定义了提交任务时的可选配置。
+type TaskOption func(*task)
+
+// Priority 设置任务的优先级，数值越大越先获得槽位，默认为 0。
+//
+// 参数:
+//
+//	n int: 任务优先级。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Priority(n int) TaskOption {
+	return func(t *task) { t.priority = n }
+}
+
+// MaxWait 限制任务等待槽位的时间，超时后 Submit 返回 ErrBusy。
+//
+// d 为 0 时只在有空闲槽位时提交，不进入等待队列。
+//
+// 参数:
+//
+//	d time.Duration: 最长等待时间。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func MaxWait(d time.Duration) TaskOption {
+	return func(t *task) { t.maxWait = max(d, 0) }
+}
+
+// Name 设置任务名称，用于统计和审计。
+//
+// 参数:
+//
+//	name string: 任务名称。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Name(name string) TaskOption {
+	return func(t *task) { t.name = name }
+}
+
+// Tenant 设置任务所属的租户，用于统计和计费。
+//
+// 参数:
+//
+//	tenant string: 租户名称。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Tenant(tenant string) TaskOption {
+	return func(t *task) { t.tenant = tenant }
+}
+
+// Labels 为任务添加标签，用于审计，可以多次使用。
+//
+// 参数:
+//
+//	labels map[string]string: 标签。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Labels(labels map[string]string) TaskOption {
+	return func(t *task) {
+		if t.labels == nil {
+			t.labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			t.labels[k] = v
+		}
+	}
+}
+
+// Submitter 设置任务的提交者，用于审计。
+//
+// 参数:
+//
+//	submitter string: 提交者标识，例如用户名或服务名。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Submitter(submitter string) TaskOption {
+	return func(t *task) { t.submitter = submitter }
+}
+
+// task 记录一个已提交任务的调度状态。
+type task struct {
+	pool      *Pool
+	f         func(ctx context.Context, index int64)
+	priority  int               // 任务优先级
+	ticket    Ticket            // 在等待队列中的凭证
+	ready     chan struct{}     // 获得槽位时关闭
+	holding   bool              // 是否持有槽位，由 pool.mu 保护
+	started   bool              // 是否已开始运行，由 pool.mu 保护
+	key       string            // 唯一键，为空时不做去重
+	scope     UniqueScope       // 唯一键的去重范围
+	replace   bool              // 是否用新任务替换排队中的同键任务
+	maxWait   time.Duration     // 等待槽位的最长时间，小于 0 时不限制
+	inherit   bool              // 任务上下文是否继承提交时的上下文
+	fraction  float64           // 任务可用的剩余时间比例，0 表示不限制
+	reserve   time.Duration     // 为上层聚合预留的时间
+	expect    time.Duration     // 任务的预计耗时，剩余时间不足时不启动
+	parent    context.Context   // 继承的提交上下文
+	deadline  time.Time         // 任务的截止时间，零值表示不限制
+	fanout    *fanout           // 任务占用的请求级并发名额
+	fds       int               // 任务需要的文件描述符数量
+	drop      func(err error)   // 任务函数不会被调用时的回调
+	name      string            // 任务名称
+	tenant    string            // 所属租户
+	labels    map[string]string // 标签
+	submitter string            // 提交者
+	submitted time.Time         // 提交时间
+	index     int64             // 任务索引，开始运行时分配
+	startedAt time.Time         // 开始运行的时间
+
+	// 以下字段仅由任务自身的协程访问。
+	sliceStart time.Time     // 当前时间片的开始时间
+	yielded    time.Duration // 让出槽位后重新排队的总时长
+	err        error         // 任务通过 Fail 报告的错误或 panic
+}
+
+// onDrop 设置任务函数不会被调用时的回调，供等待任务结果的包装使用。
+func onDrop(f func(err error)) TaskOption {
+	return func(t *task) { t.drop = f }
+}
+
+// dropped 在任务函数不会被调用时通知提交方。
+func (t *task) dropped(err error) {
+	if t.drop != nil {
+		t.drop(err)
+	}
+}
+
+// taskKey 是任务在上下文中的键。
+type taskKey struct{}
+
+// taskFrom 从上下文中取出正在运行的任务。
+func taskFrom(ctx context.Context) *task {
+	t, _ := ctx.Value(taskKey{}).(*task)
+	return t
+}
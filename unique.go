@@ -0,0 +1,82 @@
+package bee
+
+import "errors"
+
+// ErrDuplicate 表示已有相同唯一键的任务在排队或运行。
+var ErrDuplicate = errors.New("bee: duplicate task")
+
+// ErrReplaced 表示排队中的任务被带有 Replace 的同键任务替换，其任务函数不会被调用。
+//
+// Dispatcher 中被替换的输入的 Future 以该错误完成，MapResumable 遇到替换时返回该错误。
+var ErrReplaced = errors.New("bee: task replaced")
+
+// errMerged 表示任务已合并到排队中的同键任务，无需再单独执行。
+var errMerged = errors.New("bee: task merged")
+
+// UniqueScope 定义了唯一键的去重范围。
+type UniqueScope int
+
+const (
+	// UniqueQueued 只与排队中的同键任务去重，同键任务正在运行时仍可提交。
+	UniqueQueued UniqueScope = iota
+	// UniqueActive 与排队中和运行中的同键任务去重。
+	UniqueActive
+)
+
+// Unique 为任务设置唯一键。
+//
+// 去重范围内已有同键任务时，Submit 返回 ErrDuplicate；
+// 同时使用 Replace 且同键任务仍在排队时，用新任务替换排队中的任务，Submit 返回 nil。
+//
+// 参数:
+//
+//	key string: 唯一键。
+//	scope UniqueScope: 去重范围。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Unique(key string, scope UniqueScope) TaskOption {
+	return func(t *task) { t.key, t.scope = key, scope }
+}
+
+// Replace 与 Unique 一起使用，用新任务替换排队中的同键任务，而不是拒绝新任务。
+//
+// 被替换的任务保留排队位置，但运行的是新任务的函数，原来的任务函数不会被调用。
+//
+// 返回值:
+//
+//	TaskOption: 任务选项。
+func Replace() TaskOption {
+	return func(t *task) { t.replace = true }
+}
+
+// dedupeLocked 检查任务的唯一键，并登记通过检查的任务，调用方需持有 p.mu。
+func (p *Pool) dedupeLocked(t *task) error {
+	if t.key == "" {
+		return nil
+	}
+	if old := p.unique[t.key]; old != nil {
+		switch {
+		case !old.started && t.replace:
+			// 交换回调后 t.drop 属于被替换的提交方，由 SubmitContext 通知。
+			old.f = t.f
+			old.drop, t.drop = t.drop, old.drop
+			return errMerged
+		case !old.started, t.scope == UniqueActive:
+			return ErrDuplicate
+		}
+	}
+	if p.unique == nil {
+		p.unique = make(map[string]*task)
+	}
+	p.unique[t.key] = t
+	return nil
+}
+
+// forgetLocked 在任务结束或放弃排队时注销其唯一键，调用方需持有 p.mu。
+func (p *Pool) forgetLocked(t *task) {
+	if t.key != "" && p.unique[t.key] == t {
+		delete(p.unique, t.key)
+	}
+}
@@ -0,0 +1,45 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+func TestReplaceResolvesReplacedFuture(t *testing.T) {
+	release := make(chan struct{})
+	d := bee.NewDispatcher(context.Background(), 1, func(ctx context.Context, in int) (int, error) {
+		if in == 0 {
+			<-release
+		}
+		return in, nil
+	})
+	blocker := d.Submit(0)
+
+	first := make(chan *bee.Future[int], 1)
+	go func() { first <- d.Submit(1, bee.Unique("k", bee.UniqueQueued)) }()
+	// 等第一个同键任务进入等待队列。
+	for d.Pool().Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan *bee.Future[int], 1)
+	go func() { second <- d.Submit(2, bee.Unique("k", bee.UniqueQueued), bee.Replace()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	// 替换的提交立即返回，结果在排队的任务运行后才就绪。
+	replacement := <-second
+	close(release)
+	if out, err := replacement.Wait(ctx); err != nil || out != 2 {
+		t.Fatalf("replacement Wait() = %d, %v, want 2", out, err)
+	}
+	if _, err := (<-first).Wait(ctx); !errors.Is(err, bee.ErrReplaced) {
+		t.Fatalf("replaced Wait() = %v, want ErrReplaced", err)
+	}
+	if _, err := blocker.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
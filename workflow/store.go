@@ -0,0 +1,163 @@
+package workflow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Record 是一个已完成步骤的检查点。
+type Record struct {
+	Step   string          `json:"step"`   // 步骤名称
+	Result json.RawMessage `json:"result"` // 步骤结果的 JSON 编码
+}
+
+// Store 保存工作流的步骤检查点，实现需要能被并发调用。
+type Store interface {
+	// Load 按写入顺序返回工作流的全部检查点，工作流不存在时返回空列表。
+	Load(id string) ([]Record, error)
+	// Append 追加一个检查点，返回前应保证检查点已持久化。
+	Append(id string, rec Record) error
+	// Delete 删除工作流的全部检查点。
+	Delete(id string) error
+}
+
+// NewMemoryStore 创建一个内存中的检查点存储，进程退出后检查点丢失，主要用于测试。
+//
+// 返回值:
+//
+//	*MemoryStore: 内存存储。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{logs: map[string][]Record{}}
+}
+
+// MemoryStore 是保存在内存中的检查点存储。
+type MemoryStore struct {
+	mu   sync.Mutex
+	logs map[string][]Record
+}
+
+// Load 返回工作流的全部检查点。
+func (s *MemoryStore) Load(id string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.logs[id]...), nil
+}
+
+// Append 追加一个检查点。
+func (s *MemoryStore) Append(id string, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs[id] = append(s.logs[id], rec)
+	return nil
+}
+
+// Delete 删除工作流的全部检查点。
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.logs, id)
+	return nil
+}
+
+// NewFileStore 创建一个本地文件检查点存储，每个工作流对应 dir 下的一个 JSON lines 文件。
+//
+// 参数:
+//
+//	dir string: 保存检查点文件的目录，不存在时自动创建。
+//
+// 返回值:
+//
+//	*FileStore: 文件存储。
+//	error: 创建目录失败时返回的错误。
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// FileStore 是保存在本地文件中的检查点存储。
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// path 返回工作流的检查点文件路径，文件名为 id 的 SHA-256，不同的 id 不会共用文件。
+func (s *FileStore) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".jsonl")
+}
+
+// Load 返回工作流的全部检查点。
+//
+// 崩溃时写了一半的最后一行会被截掉，之后追加的检查点从新的一行开始。
+func (s *FileStore) Load(id string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var recs []Record
+	good := 0 // 最后一个完整记录之后的偏移
+	for good < len(data) {
+		n := bytes.IndexByte(data[good:], '\n')
+		if n < 0 {
+			break
+		}
+		var rec Record
+		if err := json.Unmarshal(data[good:good+n], &rec); err != nil {
+			break
+		}
+		recs = append(recs, rec)
+		good += n + 1
+	}
+	if good < len(data) {
+		if err := os.Truncate(s.path(id), int64(good)); err != nil {
+			return nil, err
+		}
+	}
+	return recs, nil
+}
+
+// Append 追加一个检查点并同步到磁盘。
+func (s *FileStore) Append(id string, rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Delete 删除工作流的检查点文件。
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
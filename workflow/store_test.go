@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestFileStoreRecoversTornLine(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := func(step string) Record { return Record{Step: step, Result: json.RawMessage(`1`)} }
+	if err := s.Append("wf", rec("a")); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟崩溃时写了一半的记录。
+	f, err := os.OpenFile(s.path("wf"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"step":"b","res`)
+	f.Close()
+
+	if recs, err := s.Load("wf"); err != nil || len(recs) != 1 {
+		t.Fatalf("Load() = %v, %v, want [a]", recs, err)
+	}
+	for _, step := range []string{"b", "c"} {
+		if err := s.Append("wf", rec(step)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recs, err := s.Load("wf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []string
+	for _, r := range recs {
+		steps = append(steps, r.Step)
+	}
+	if len(steps) != 3 || steps[0] != "a" || steps[1] != "b" || steps[2] != "c" {
+		t.Fatalf("Load() steps = %v, want [a b c]", steps)
+	}
+}
+
+func TestFileStoreSeparatesIDs(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append("a/x", Record{Step: "a", Result: json.RawMessage(`1`)}); err != nil {
+		t.Fatal(err)
+	}
+	if recs, err := s.Load("b/x"); err != nil || len(recs) != 0 {
+		t.Fatalf("Load(b/x) = %v, %v, want no records", recs, err)
+	}
+}
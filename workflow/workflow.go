@@ -0,0 +1,233 @@
+// Package workflow 提供带步骤检查点的持久化工作流。
+//
+// 工作流是一段调用 Step 的普通 Go 代码。每个步骤在线程池中执行，完成后结果写入检查点存储；
+// 进程崩溃后以相同 id 重新运行工作流时，已完成的步骤直接返回记录的结果，
+// 从第一个未完成的步骤继续执行。
+//
+// 步骤名称在一次运行中必须唯一，工作流代码需要是确定性的，即重放时以相同的顺序调用相同名称的步骤。
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cnk3x/bee"
+)
+
+// ErrDuplicateStep 表示一次运行中出现了重复的步骤名称。
+var ErrDuplicateStep = errors.New("workflow: duplicate step")
+
+// StepFunc 是步骤函数，返回值会以 JSON 编码保存到检查点中。
+type StepFunc func(ctx context.Context) (any, error)
+
+// Result 是步骤结果的 JSON 编码。
+type Result json.RawMessage
+
+// Decode 将步骤结果解码到 v 中。
+//
+// 参数:
+//
+//	v any: 解码目标，通常为指针。
+//
+// 返回值:
+//
+//	error: 解码失败时返回的错误。
+func (r Result) Decode(v any) error {
+	return json.Unmarshal(r, v)
+}
+
+// New 创建一个工作流引擎。
+//
+// 参数:
+//
+//	p *bee.Pool: 执行步骤的线程池。
+//	store Store: 检查点存储。
+//
+// 返回值:
+//
+//	*Engine: 工作流引擎。
+func New(p *bee.Pool, store Store) *Engine {
+	return &Engine{pool: p, store: store}
+}
+
+// Engine 运行工作流并管理其检查点。
+type Engine struct {
+	pool  *bee.Pool
+	store Store
+}
+
+// Run 运行 id 对应的工作流。
+//
+// 已有检查点时，已完成的步骤会被重放而不再执行。fn 返回后 Run 等待通过 Go 启动的步骤全部结束，
+// 工作流成功结束后删除检查点，返回错误时保留检查点，以便下次运行时从失败的步骤继续。
+//
+// 参数:
+//
+//	ctx context.Context: 控制工作流的上下文，取消时正在执行的步骤收到的上下文也会被取消。
+//	id string: 工作流实例的标识。
+//	fn func(wf *Workflow) error: 工作流代码。
+//
+// 返回值:
+//
+//	error: 加载检查点失败或工作流代码返回的错误。
+func (e *Engine) Run(ctx context.Context, id string, fn func(wf *Workflow) error) error {
+	recs, err := e.store.Load(id)
+	if err != nil {
+		return err
+	}
+	wf := &Workflow{
+		ctx:    ctx,
+		id:     id,
+		engine: e,
+		done:   make(map[string]Result, len(recs)),
+		seen:   map[string]bool{},
+	}
+	for _, rec := range recs {
+		wf.done[rec.Step] = Result(rec.Result)
+	}
+	err = fn(wf)
+	// 未等待的步骤结束后才能删除检查点，否则它们会在删除后写入新的检查点。
+	wf.steps.Wait()
+	if err != nil {
+		return err
+	}
+	return e.store.Delete(id)
+}
+
+// Workflow 是一次工作流运行，传给工作流代码使用。
+type Workflow struct {
+	ctx    context.Context
+	id     string
+	engine *Engine
+
+	mu    sync.Mutex
+	done  map[string]Result // 已完成步骤的结果
+	seen  map[string]bool   // 本次运行中已调用的步骤
+	steps sync.WaitGroup    // 正在执行的步骤
+}
+
+// Context 返回工作流的上下文。
+//
+// 返回值:
+//
+//	context.Context: 传给 Engine.Run 的上下文。
+func (w *Workflow) Context() context.Context {
+	return w.ctx
+}
+
+// ID 返回工作流实例的标识。
+//
+// 返回值:
+//
+//	string: 工作流实例的标识。
+func (w *Workflow) ID() string {
+	return w.id
+}
+
+// Step 执行一个步骤并等待其结束，已完成的步骤直接返回记录的结果。
+//
+// 参数:
+//
+//	name string: 步骤名称，在一次运行中唯一。
+//	f StepFunc: 步骤函数。
+//
+// 返回值:
+//
+//	Result: 步骤结果。
+//	error: 步骤函数返回的错误或保存检查点失败的错误。
+func (w *Workflow) Step(name string, f StepFunc) (Result, error) {
+	return w.Go(name, f).Wait()
+}
+
+// Go 在线程池中异步执行一个步骤，用于并行执行多个步骤。
+//
+// 参数:
+//
+//	name string: 步骤名称，在一次运行中唯一。
+//	f StepFunc: 步骤函数。
+//
+// 返回值:
+//
+//	*Pending: 等待步骤结束的句柄。
+func (w *Workflow) Go(name string, f StepFunc) *Pending {
+	p := &Pending{done: make(chan struct{})}
+
+	w.mu.Lock()
+	if w.seen[name] {
+		w.mu.Unlock()
+		p.finish(nil, fmt.Errorf("%w: %s", ErrDuplicateStep, name))
+		return p
+	}
+	w.seen[name] = true
+	res, ok := w.done[name]
+	w.mu.Unlock()
+	if ok {
+		p.finish(res, nil)
+		return p
+	}
+
+	w.steps.Add(1)
+	err := w.engine.pool.SubmitContext(w.ctx, func(tctx context.Context, _ int64) {
+		defer w.steps.Done()
+		tctx, cancel := context.WithCancel(tctx)
+		defer cancel()
+		defer context.AfterFunc(w.ctx, cancel)()
+		p.finish(w.run(tctx, name, f))
+	}, bee.Name(name))
+	if err != nil {
+		w.steps.Done()
+		p.finish(nil, err)
+	}
+	return p
+}
+
+// run 执行步骤函数并保存检查点。
+func (w *Workflow) run(ctx context.Context, name string, f StepFunc) (res Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &bee.PanicError{Value: r}
+		}
+	}()
+	v, err := f(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.engine.store.Append(w.id, Record{Step: name, Result: data}); err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	w.done[name] = data
+	w.mu.Unlock()
+	return data, nil
+}
+
+// Pending 是异步执行中的步骤。
+type Pending struct {
+	done chan struct{}
+	res  Result
+	err  error
+}
+
+// finish 记录步骤结果。
+func (p *Pending) finish(res Result, err error) {
+	p.res, p.err = res, err
+	close(p.done)
+}
+
+// Wait 等待步骤结束。
+//
+// 返回值:
+//
+//	Result: 步骤结果。
+//	error: 步骤函数返回的错误或保存检查点失败的错误。
+func (p *Pending) Wait() (Result, error) {
+	<-p.done
+	return p.res, p.err
+}
@@ -0,0 +1,43 @@
+package workflow_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+	"github.com/cnk3x/bee/workflow"
+)
+
+func TestRunWaitsForUnwaitedSteps(t *testing.T) {
+	p := bee.New(context.Background(), 2)
+	defer p.Exit()
+	store, err := workflow.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := workflow.New(p, store)
+
+	var runs atomic.Int32
+	fn := func(wf *workflow.Workflow) error {
+		wf.Go("slow", func(context.Context) (any, error) {
+			runs.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return 1, nil
+		})
+		return nil
+	}
+	if err := e.Run(context.Background(), "wf", fn); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if recs, err := store.Load("wf"); err != nil || len(recs) != 0 {
+		t.Fatalf("checkpoint after a successful Run = %v, %v, want none", recs, err)
+	}
+	if err := e.Run(context.Background(), "wf", fn); err != nil {
+		t.Fatalf("second Run() = %v", err)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("step ran %d times over two fresh runs, want 2", n)
+	}
+}
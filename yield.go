@@ -0,0 +1,80 @@
+package bee
+
+import (
+	"context"
+	"time"
+)
+
+// Yield 是长时间运行的计算型任务的协作让出点。
+//
+// 任务应在循环中定期调用 Yield。当任务已用完时间片、且等待队列中有优先级更高的任务时，
+// 当前任务会让出槽位并重新排队，等再次获得槽位后 Yield 才返回，从而让高优先级任务先执行。
+// 等待期间上下文被取消时，Yield 仍等到重新获得槽位后才返回上下文的错误，任务不会在没有槽位时继续运行。
+// 不在线程池任务中调用时，Yield 仅返回上下文的错误。
+//
+// 参数:
+//
+//	ctx context.Context: 任务函数收到的上下文。
+//
+// 返回值:
+//
+//	error: 上下文已取消时返回上下文的错误，任务应尽快结束。
+func Yield(ctx context.Context) error {
+	t := taskFrom(ctx)
+	if t == nil {
+		return ctx.Err()
+	}
+	return t.pool.yield(ctx, t)
+}
+
+// yield 在时间片用完且有更高优先级任务等待时让出槽位。
+func (p *Pool) yield(ctx context.Context, t *task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if time.Since(t.sliceStart) < p.timeSlice {
+		return nil
+	}
+
+	p.mu.Lock()
+	if !t.holding || !p.preemptLocked(t) {
+		p.mu.Unlock()
+		t.sliceStart = time.Now()
+		return nil
+	}
+	// 让出槽位，重新排到等待队列中。
+	t.holding = false
+	p.running.Add(-1)
+	p.pushLocked(t)
+	p.dispatchLocked()
+	p.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-t.ready:
+		t.sliceStart = time.Now()
+		t.yielded += t.sliceStart.Sub(start)
+		return nil
+	case <-ctx.Done():
+		// 任务返回后仍会继续运行，必须重新持有槽位，否则会超过线程池的容量。
+		<-t.ready
+		t.sliceStart = time.Now()
+		t.yielded += t.sliceStart.Sub(start)
+		return ctx.Err()
+	}
+}
+
+// preemptLocked 判断等待队列中是否有应当先于 t 运行的任务，调用方需持有 p.mu。
+func (p *Pool) preemptLocked(t *task) bool {
+	if len(p.queued) == 0 {
+		return false
+	}
+	if p.head != nil {
+		return p.head.priority > t.priority
+	}
+	if pk, ok := p.waiting.(Peeker); ok {
+		next := pk.Peek()
+		return next != nil && next.Priority > t.priority
+	}
+	return true
+}
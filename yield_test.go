@@ -0,0 +1,152 @@
+package bee_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnk3x/bee"
+)
+
+// recorder 按发生顺序记录事件。
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(e string) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// spinUntil 在任务中循环调用 Yield，直到 stop 返回 true。
+func spinUntil(ctx context.Context, stop func() bool) error {
+	for !stop() {
+		if err := bee.Yield(ctx); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+	return nil
+}
+
+func TestYieldPreemptsForHigherPriority(t *testing.T) {
+	p := bee.New(context.Background(), 1, bee.WithTimeSlice(time.Millisecond))
+	defer p.Exit()
+	var (
+		rec     recorder
+		highRan atomic.Bool
+		started = make(chan struct{})
+		done    = make(chan struct{})
+	)
+	if err := p.Submit(func(ctx context.Context, _ int64) {
+		defer close(done)
+		close(started)
+		spinUntil(ctx, highRan.Load)
+		rec.add("low done")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := p.Submit(func(context.Context, int64) {
+		rec.add("high")
+		highRan.Store(true)
+	}, bee.Priority(1)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("low priority task never yielded to the high priority task")
+	}
+	if got := rec.get(); len(got) != 2 || got[0] != "high" {
+		t.Fatalf("events = %v, want high before low done", got)
+	}
+}
+
+func TestYieldKeepsSlotWithinTimeSlice(t *testing.T) {
+	p := bee.New(context.Background(), 1, bee.WithTimeSlice(time.Hour))
+	defer p.Exit()
+	var (
+		rec     recorder
+		started = make(chan struct{})
+		highRan = make(chan struct{})
+	)
+	if err := p.Submit(func(ctx context.Context, _ int64) {
+		close(started)
+		deadline := time.Now().Add(30 * time.Millisecond)
+		spinUntil(ctx, func() bool { return time.Now().After(deadline) })
+		rec.add("low done")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := p.Submit(func(context.Context, int64) {
+		rec.add("high")
+		close(highRan)
+	}, bee.Priority(1)); err != nil {
+		t.Fatal(err)
+	}
+	<-highRan
+	if got := rec.get(); len(got) != 2 || got[0] != "low done" {
+		t.Fatalf("events = %v, want low done before high", got)
+	}
+}
+
+func TestYieldCanceledWaitsForSlot(t *testing.T) {
+	p := bee.New(context.Background(), 1, bee.WithTimeSlice(time.Millisecond))
+	defer p.Exit()
+	var (
+		highRan    atomic.Bool
+		started    = make(chan struct{})
+		highIn     = make(chan struct{})
+		release    = make(chan struct{})
+		lowErr     = make(chan error, 1)
+		ctx, abort = context.WithCancel(context.Background())
+	)
+	defer abort()
+
+	if err := p.SubmitContext(ctx, func(tctx context.Context, _ int64) {
+		close(started)
+		lowErr <- spinUntil(tctx, highRan.Load)
+	}, bee.Inherit()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := p.Submit(func(context.Context, int64) {
+		highRan.Store(true)
+		close(highIn)
+		<-release
+	}, bee.Priority(1)); err != nil {
+		t.Fatal(err)
+	}
+	<-highIn
+
+	// 低优先级任务在等待槽位时被取消，必须等高优先级任务结束、重新获得槽位后才继续运行。
+	abort()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-lowErr:
+		t.Fatalf("Yield() returned %v while the slot was still taken", err)
+	default:
+	}
+	close(release)
+	select {
+	case err := <-lowErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Yield() = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("canceled task never got its slot back")
+	}
+}